@@ -1,5 +1,6 @@
 //go:generate protoc --go_out=../../internal/server/pb --proto_path=../../api/ ../../api/Service.proto
 //go:generate protoc --go-grpc_out=../../internal/server/pb --proto_path=../../api/ ../../api/Service.proto
+//go:generate protoc --go_out=../../internal/server/pb --proto_path=../../api/ ../../api/Event.proto
 package main
 
 import (
@@ -16,10 +17,14 @@ import (
 	"github.com/pkg/errors"
 )
 
-var bannerConfigFile string
+var (
+	bannerConfigFile string
+	checkConfig      bool
+)
 
 func init() {
 	flag.StringVar(&bannerConfigFile, "config", "banner_config.yaml", "Path to configuration file")
+	flag.BoolVar(&checkConfig, "check-config", false, "Validate the configuration file and exit")
 }
 
 func main() {
@@ -34,6 +39,11 @@ func mainImpl() error {
 
 	flag.Parse()
 
+	if flag.Arg(0) == "version" {
+		printVersion()
+		return nil
+	}
+
 	if bannerConfigFile == "" {
 		return fmt.Errorf("please set: '--config=<Path to configuration file>'")
 	}
@@ -42,8 +52,15 @@ func mainImpl() error {
 	if err := conf.Init(bannerConfigFile); err != nil {
 		return errors.Wrap(err, "failed to init config")
 	}
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	if checkConfig {
+		fmt.Printf("Configuration file %s is valid\n", bannerConfigFile)
+		return nil
+	}
 
-	_, err := banner.NewApp(ctx, conf)
+	app, err := banner.NewApp(ctx, conf, buildInfo())
 	if err != nil {
 		return fmt.Errorf("failed to create app: %w", err)
 	}
@@ -52,5 +69,8 @@ func mainImpl() error {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
 
+	// Exiting only once the queued events are flushed.
+	app.Shutdown(context.Background())
+
 	return nil
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cronnoss/banners-rotation/internal/server/admin"
+)
+
+// Set by -ldflags, see LDFLAGS in the Makefile.
+var (
+	release   = "UNKNOWN"
+	buildDate = "UNKNOWN"
+	gitHash   = "UNKNOWN"
+)
+
+func buildInfo() admin.BuildInfo {
+	return admin.BuildInfo{
+		Release:   release,
+		BuildDate: buildDate,
+		GitHash:   gitHash,
+	}
+}
+
+func printVersion() {
+	if err := json.NewEncoder(os.Stdout).Encode(buildInfo()); err != nil {
+		fmt.Printf("error while decode version info: %v\n", err)
+	}
+}
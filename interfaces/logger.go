@@ -1,8 +1,16 @@
 package interfaces
 
+import "context"
+
 type Logger interface {
 	Debug(msg string, a ...any)
 	Info(msg string, a ...any)
 	Warning(msg string, a ...any)
 	Error(msg string, a ...any)
+
+	// The context-aware methods add the request ID stored in the context to the message.
+	DebugContext(ctx context.Context, msg string, a ...any)
+	InfoContext(ctx context.Context, msg string, a ...any)
+	WarningContext(ctx context.Context, msg string, a ...any)
+	ErrorContext(ctx context.Context, msg string, a ...any)
 }
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+)
+
+type EventPublisher interface {
+	Publish(ctx context.Context, notification storage.Notification) error
+	Close() error
+}
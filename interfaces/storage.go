@@ -9,13 +9,17 @@ import (
 type Storage interface {
 	Connect(ctx context.Context, dbPort int, dbHost, dbUser, dbPassword, dbName string) error
 	Close(ctx context.Context) error
+	Ping(ctx context.Context) error
 	Migrate(ctx context.Context, migrate string) error
 	AddBanner(ctx context.Context, bannerID, slotID int) error
 	RemoveBanner(ctx context.Context, bannerID, slotID int) error
 	ClickBanner(ctx context.Context, bannerID, slotID, userGroupID int) (*storage.Click, error)
+	ClickImpression(ctx context.Context, impressionID, bannerID, slotID, userGroupID int) (*storage.Click, error)
 	PickBanner(ctx context.Context, slotID, usergroupID int) (*storage.Impress, int, error)
+	ConfirmImpression(ctx context.Context, impressionID int) (*storage.Impress, error)
+	BannerTargetURL(ctx context.Context, bannerID int) (string, error)
+	APIKeyRole(ctx context.Context, keyHash string) (string, error)
+	CountAPIKeys(ctx context.Context) (int, error)
 	IsBannerAssignedToSlot(ctx context.Context, bannerID, slotID int) (bool, error)
-	BannerExists(ctx context.Context, bannerID int) bool
-	SlotExists(ctx context.Context, slotID int) bool
-	UserGroupExists(ctx context.Context, userGroupID int) bool
+	Exist(ctx context.Context, bannerID, slotID, userGroupID int) (storage.Existence, error)
 }
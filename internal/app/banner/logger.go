@@ -0,0 +1,62 @@
+package banner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/config"
+	"github.com/cronnoss/banners-rotation/internal/logger"
+)
+
+// newLogOutput opens the destination of the log. The file is returned as well to be reopened and closed.
+func newLogOutput(conf *config.BannerConfig) (io.Writer, *logger.FileOutput, error) {
+	switch conf.Logger.Output {
+	case logger.OutputStdout, "":
+		return os.Stdout, nil, nil
+	case logger.OutputStderr:
+		return os.Stderr, nil, nil
+	case logger.OutputFile:
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", conf.Logger.Output)
+	}
+
+	fileConf := conf.Logger.File
+	if fileConf.Path == "" {
+		return nil, nil, fmt.Errorf("log file path is not set")
+	}
+	var rotateInterval time.Duration
+	if fileConf.RotateInterval != "" {
+		var err error
+		rotateInterval, err = time.ParseDuration(fileConf.RotateInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("log rotate interval parsing fail (%s): %w", fileConf.RotateInterval, err)
+		}
+	}
+
+	file := logger.NewFileOutput(
+		fileConf.Path,
+		fileConf.MaxSize,
+		fileConf.MaxAge,
+		fileConf.MaxBackups,
+		fileConf.Compress,
+		rotateInterval,
+	)
+	return file, file, nil
+}
+
+// reopenOnSIGHUP reopens the log file on SIGHUP, as expected by logrotate after it has moved the file.
+func reopenOnSIGHUP(file *logger.FileOutput, logg *logger.Logger) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	for range sigChan {
+		if err := file.Reopen(); err != nil {
+			logg.Error("Failed to reopen log file: %v", err)
+			continue
+		}
+		logg.Info("Log file reopened")
+	}
+}
@@ -0,0 +1,109 @@
+package clicktoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid click token")
+	ErrExpiredToken = errors.New("expired click token")
+)
+
+// sigSize is the number of HMAC bytes kept in a token.
+const sigSize = 16
+
+// Click identifies the impression of a banner shown in a slot to a user group.
+type Click struct {
+	BannerID     int32
+	SlotID       int32
+	UserGroupID  int32
+	ImpressionID int32
+}
+
+// Signer issues and verifies click tokens. A token is the click IDs and an expiry signed with HMAC-SHA256,
+// so tracking links cannot be forged for arbitrary banners nor replayed forever. The impression ID lets
+// the server record a single click per impression.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewSigner returns a signer of click tokens valid for ttl.
+func NewSigner(secret string, ttl time.Duration) *Signer {
+	return &Signer{secret: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+// Sign returns the token for the click, in the form "<banner>.<slot>.<usergroup>.<impression>.<expiry>.<signature>",
+// the expiry being a Unix time.
+func (s *Signer) Sign(click Click) string {
+	payload := fmt.Sprintf("%d.%d.%d.%d.%d", click.BannerID, click.SlotID, click.UserGroupID, click.ImpressionID,
+		s.now().Add(s.ttl).Unix())
+	return payload + "." + s.signature(payload)
+}
+
+// Verify returns the click of a token issued by Sign. It returns ErrExpiredToken for a valid but expired token.
+func (s *Signer) Verify(token string) (Click, error) {
+	payload, err := s.verify(token)
+	if err != nil {
+		return Click{}, err
+	}
+
+	var (
+		click     Click
+		expiresAt int64
+	)
+	if _, err := fmt.Sscanf(payload, "%d.%d.%d.%d.%d",
+		&click.BannerID, &click.SlotID, &click.UserGroupID, &click.ImpressionID, &expiresAt); err != nil {
+		return Click{}, ErrInvalidToken
+	}
+	if !s.now().Before(time.Unix(expiresAt, 0)) {
+		return Click{}, ErrExpiredToken
+	}
+	return click, nil
+}
+
+// SignImpression returns the token of the impression beacon, in the form "i.<impression>.<signature>".
+func (s *Signer) SignImpression(impressionID int32) string {
+	payload := fmt.Sprintf("i.%d", impressionID)
+	return payload + "." + s.signature(payload)
+}
+
+// VerifyImpression returns the impression ID of a token issued by SignImpression.
+func (s *Signer) VerifyImpression(token string) (int32, error) {
+	payload, err := s.verify(token)
+	if err != nil {
+		return 0, err
+	}
+
+	var impressionID int32
+	if _, err := fmt.Sscanf(payload, "i.%d", &impressionID); err != nil {
+		return 0, ErrInvalidToken
+	}
+	return impressionID, nil
+}
+
+// verify checks the signature of the token and returns its payload.
+func (s *Signer) verify(token string) (string, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", ErrInvalidToken
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.signature(payload))) {
+		return "", ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func (s *Signer) signature(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:sigSize])
+}
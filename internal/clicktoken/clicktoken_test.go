@@ -0,0 +1,74 @@
+package clicktoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	s := NewSigner("secret", time.Hour)
+	click := Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 4}
+
+	token := s.Sign(click)
+	require.True(t, strings.HasPrefix(token, "1.2.3.4."))
+
+	got, err := s.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, click, got)
+}
+
+func TestVerifyExpiredToken(t *testing.T) {
+	now := time.Now()
+	s := NewSigner("secret", time.Hour)
+	s.now = func() time.Time { return now }
+	token := s.Sign(Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 4})
+
+	s.now = func() time.Time { return now.Add(59 * time.Minute) }
+	_, err := s.Verify(token)
+	require.NoError(t, err)
+
+	s.now = func() time.Time { return now.Add(time.Hour) }
+	_, err = s.Verify(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+}
+
+func TestVerifyRejectsForgedTokens(t *testing.T) {
+	s := NewSigner("secret", time.Hour)
+	token := s.Sign(Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 4})
+	i := strings.LastIndexByte(token, '.')
+	payload, sig := token[:i], token[i:]
+	expiry := payload[strings.LastIndexByte(payload, '.'):]
+
+	for _, forged := range []string{
+		"",
+		"1.2.3",
+		"9.2.3.4" + expiry + sig,
+		"1.2.3.5" + expiry + sig,
+		"1.2.3.4.99999999999" + sig,
+		"1.2.3" + NewSigner("secret", time.Hour).Sign(Click{})[len("0.0.0"):],
+		NewSigner("other", time.Hour).Sign(Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 4}),
+		token + "x",
+	} {
+		_, err := s.Verify(forged)
+		require.ErrorIs(t, err, ErrInvalidToken, forged)
+	}
+}
+
+func TestSignVerifyImpression(t *testing.T) {
+	s := NewSigner("secret", time.Hour)
+
+	id, err := s.VerifyImpression(s.SignImpression(42))
+	require.NoError(t, err)
+	require.Equal(t, int32(42), id)
+
+	// Click and impression tokens are not interchangeable.
+	_, err = s.VerifyImpression(s.Sign(Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 42}))
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = s.Verify(s.SignImpression(42))
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = NewSigner("other", time.Hour).VerifyImpression(s.SignImpression(42))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
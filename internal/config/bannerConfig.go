@@ -7,16 +7,22 @@ import (
 var _ Configure = (*BannerConfig)(nil)
 
 type BannerConfig struct {
-	Logger   LoggerConf   `json:"logger"`
-	FilePath string       `json:"file_path"` //nolint:tagliatelle
-	Database DataBaseConf `json:"database"`
-	GRPC     GRPC         `json:"grpc"`
-	Storage  StorageConf  `json:"storage"`
-	RMQ      RMQ          `json:"rmq"`
-	Queues   struct {
+	Logger    LoggerConf    `json:"logger"`
+	FilePath  string        `json:"file_path"` //nolint:tagliatelle
+	Database  DataBaseConf  `json:"database"`
+	GRPC      GRPC          `json:"grpc"`
+	HTTP      HTTP          `json:"http"`
+	Admin     Admin         `json:"admin"`
+	Tracing   TracingConf   `json:"tracing"`
+	Auth      AuthConf      `json:"auth"`
+	RateLimit RateLimitConf `json:"rateLimit"`
+	Storage   StorageConf   `json:"storage"`
+	RMQ       RMQ           `json:"rmq"`
+	Queues    struct {
 		Events Queue
 	}
-	Consumer Consumer
+	Consumer  Consumer
+	Publisher PublisherConf `json:"publisher"`
 }
 
 func (b *BannerConfig) Init(file string) error {
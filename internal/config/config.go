@@ -1,8 +1,6 @@
 package config
 
 import (
-	"strings"
-
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
@@ -11,13 +9,32 @@ type Configure interface {
 	Init(file string) error
 }
 
+// LoggerConf keys are prefixed in the config file, so they need mapstructure tags to be decoded by viper.
 type LoggerConf struct {
-	Level       string `json:"loggerLevel"`
-	Development bool   `json:"loggerDevelopment"`
+	Level       string      `json:"loggerLevel" mapstructure:"loggerLevel"`
+	Format      string      `json:"loggerFormat" mapstructure:"loggerFormat"` // One of "text", "json".
+	Output      string      `json:"loggerOutput" mapstructure:"loggerOutput"` // One of "stdout", "stderr", "file".
+	File        LogFileConf `json:"loggerFile" mapstructure:"loggerFile"`
+	Development bool        `json:"loggerDevelopment" mapstructure:"loggerDevelopment"`
+	// Proto names of the message fields masked in the logged gRPC requests and responses.
+	RedactFields []string `json:"loggerRedactFields" mapstructure:"loggerRedactFields"`
+	// Share of the successful gRPC calls logged, from 0 to 1, keyed by the method name. Other methods are always logged.
+	Sampling map[string]float64 `json:"loggerSampling" mapstructure:"loggerSampling"`
+}
+
+type LogFileConf struct {
+	Path           string `json:"path"`
+	MaxSize        int    `json:"maxSize"`        // Megabytes, the file is rotated when it grows over it. Zero means 100.
+	MaxAge         int    `json:"maxAge"`         // Days, older rotated files are removed. Zero keeps them.
+	MaxBackups     int    `json:"maxBackups"`     // Number of rotated files kept. Zero keeps all.
+	Compress       bool   `json:"compress"`       // Rotated files are gzipped.
+	RotateInterval string `json:"rotateInterval"` // The file is also rotated at this interval when set.
 }
 
 type StorageConf struct {
 	Migration string `json:"migration"`
+	// Viewability counts only impressions confirmed by the impression beacon.
+	Viewability bool `json:"viewability"`
 }
 
 type DataBaseConf struct {
@@ -29,10 +46,57 @@ type DataBaseConf struct {
 }
 
 type GRPC struct {
-	Host string `json:"host"`
+	Host                string `json:"host"`
+	Port                int    `json:"port"`
+	Reflection          bool   `json:"reflection"`
+	HealthCheckInterval string `json:"healthCheckInterval"`
+}
+
+type HTTP struct { // The HTTP gateway is disabled when Port is 0.
+	Host             string `json:"host"`
+	Port             int    `json:"port"`
+	ClickTokenSecret string `json:"clickTokenSecret"` // Click-redirect tokens are disabled when empty, else at least 16 bytes.
+	ClickTokenTTL    string `json:"clickTokenTtl"`    // Click-redirect links expire after it.
+	TrackingAPIKey   string `json:"trackingApiKey"`   // Client API key used by the click and beacon endpoints.
+}
+
+type Admin struct { // The admin server (metrics, pprof, config dump, log level) is disabled when Port is 0.
+	Host string `json:"host"` // Internal only: pprof, config and log level changes are open unless auth is enabled.
 	Port int    `json:"port"`
 }
 
+type TracingConf struct { // Tracing is disabled when Exporter is empty or "none".
+	Exporter    string `json:"exporter"` // One of "otlp", "stdout", "none".
+	Endpoint    string `json:"endpoint"` // OTLP gRPC collector address.
+	Insecure    bool   `json:"insecure"` // Disables TLS to the OTLP collector.
+	ServiceName string `json:"serviceName"`
+}
+
+type AuthConf struct { // Authentication is disabled when Enabled is false.
+	Enabled        bool   `json:"enabled"`
+	APIKeyCacheTTL string `json:"apiKeyCacheTtl"`
+	JWT            struct {
+		HMACSecret       string `json:"hmacSecret"`       // HS256 tokens are rejected when empty.
+		RSAPublicKeyFile string `json:"rsaPublicKeyFile"` // RS256 tokens are rejected when empty.
+		Issuer           string `json:"issuer"`
+		Audience         string `json:"audience"`
+	}
+}
+
+type RateLimitConf struct { // Rate limiting is disabled when Enabled is false.
+	Enabled     bool                     `json:"enabled"`
+	Rate        float64                  `json:"rate"` // Requests per second per client, 0 means no limit.
+	Burst       int                      `json:"burst"`
+	IdleTimeout string                   `json:"idleTimeout"` // Limiters of idle clients are dropped after it.
+	Methods     map[string]RateLimitRule `json:"methods"`     // Per-method limits, keyed by the method name.
+	IP          RateLimitRule            `json:"ip"`          // Limit of every IP over all methods, before auth.
+}
+
+type RateLimitRule struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
 type RMQ struct {
 	RabbitmqProtocol string `json:"rabbitmqProtocol"`
 	RabbitmqUsername string `json:"rabbitmqUsername"`
@@ -45,6 +109,18 @@ type RMQ struct {
 		Multiplier      float64 `json:"multiplier"`
 		MaxInterval     string  `json:"maxInterval"`
 	}
+	Spool struct { // Spooling is disabled when Dir is empty.
+		Dir         string `json:"dir"`
+		SegmentSize int64  `json:"segmentSize"` // Bytes.
+		MaxSize     int64  `json:"maxSize"`     // Bytes.
+	}
+	Async struct {
+		Enabled       bool   `json:"enabled"`
+		QueueSize     int    `json:"queueSize"`
+		BatchSize     int    `json:"batchSize"`
+		FlushInterval string `json:"flushInterval"`
+		Policy        string `json:"policy"` // One of "block", "drop-oldest", "drop-new".
+	}
 }
 
 type Queue struct {
@@ -60,9 +136,32 @@ type Consumer struct {
 	Threads          float64 `json:"threads"`
 }
 
+type PublisherConf struct {
+	Sinks    []string    `json:"sinks"`    // Any of "rmq", "file", "webhook", "noop".
+	Encoding string      `json:"encoding"` // One of "json", "protobuf", "cloudevents".
+	Source   string      `json:"source"`   // CloudEvents "source" attribute.
+	Fallback string      `json:"fallback"` // Sink used while RMQ reconnection has exhausted its backoff.
+	File     FileSink    `json:"file"`
+	Webhook  WebhookSink `json:"webhook"`
+}
+
+type FileSink struct {
+	Path string `json:"path"`
+}
+
+type WebhookSink struct {
+	URL     string `json:"url"`
+	Timeout string `json:"timeout"`
+}
+
+// Init reads the config file into c. The keys are overridden by the environment variables listed by EnvBindings.
 func Init(file string, c Configure) (Configure, error) {
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	// Every key is bound explicitly: the keys missing from the file are overridden as well.
+	for _, binding := range EnvBindings(c) {
+		if err := viper.BindEnv(binding.Key, binding.Env); err != nil {
+			return nil, errors.Wrapf(err, "bind env %s failed", binding.Env)
+		}
+	}
 
 	viper.SetConfigFile(file)
 
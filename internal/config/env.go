@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// EnvPrefix prefixes the environment variables overriding the config keys.
+const EnvPrefix = "BANNER"
+
+// EnvBinding maps a config key to the environment variable overriding it.
+type EnvBinding struct {
+	Key string // Key as written in the config file, e.g. "rmq.reConnect.maxElapsedTime".
+	Env string // e.g. "BANNER_RMQ_RE_CONNECT_MAX_ELAPSED_TIME".
+}
+
+// EnvBindings lists the environment variables of every key of c, a pointer to a config struct.
+// The variable is EnvPrefix followed by the key path in upper snake case; a key repeating the name
+// of its section as a prefix drops it, so "logger.loggerLevel" is BANNER_LOGGER_LEVEL.
+// Lists are comma-separated. Maps, such as rateLimit.methods, are only read from the config file.
+func EnvBindings(c interface{}) []EnvBinding {
+	var bindings []EnvBinding
+	collectEnvBindings(reflect.TypeOf(c).Elem(), "", EnvPrefix, "", &bindings)
+	return bindings
+}
+
+func collectEnvBindings(t reflect.Type, keyPrefix, envPrefix, section string, bindings *[]EnvBinding) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := keyName(field)
+		key := keyPrefix + name
+
+		envName := name
+		if lower := strings.ToLower(name); section != "" && len(lower) > len(section) && strings.HasPrefix(lower, section) {
+			envName = name[len(section):]
+		}
+		env := envPrefix + "_" + upperSnake(envName)
+
+		switch field.Type.Kind() { //nolint:exhaustive
+		case reflect.Struct:
+			collectEnvBindings(field.Type, key+".", env, strings.ToLower(name), bindings)
+		case reflect.Map:
+		default:
+			*bindings = append(*bindings, EnvBinding{Key: key, Env: env})
+		}
+	}
+}
+
+// keyName returns the config file key of a field. Viper decodes with the mapstructure tag, else with the field
+// name case-insensitively, so the json tag is only the key when it matches the field name.
+func keyName(field reflect.StructField) string {
+	if tag, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ","); tag != "" {
+		return tag
+	}
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); strings.EqualFold(tag, field.Name) {
+		return tag
+	}
+	return lowerCamel(field.Name)
+}
+
+// lowerCamel lowers the leading word of a Go name, e.g. "JWT" and "HMACSecret" become "jwt" and "hmacSecret".
+func lowerCamel(s string) string {
+	runes := []rune(s)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// upperSnake converts a camel case name, acronyms included, e.g. "apiKeyCacheTtl" and "HMACSecret"
+// become API_KEY_CACHE_TTL and HMAC_SECRET.
+func upperSnake(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || unicode.IsUpper(runes[i-1]) && nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
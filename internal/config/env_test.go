@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvBindings(t *testing.T) {
+	envs := make(map[string]string)
+	for _, binding := range EnvBindings(&BannerConfig{}) {
+		require.True(t, strings.HasPrefix(binding.Env, EnvPrefix+"_"), binding.Env)
+		_, duplicate := envs[binding.Env]
+		require.False(t, duplicate, binding.Env)
+		envs[binding.Env] = binding.Key
+	}
+
+	require.Equal(t, "logger.loggerLevel", envs["BANNER_LOGGER_LEVEL"])
+	require.Equal(t, "logger.loggerFile.maxSize", envs["BANNER_LOGGER_FILE_MAX_SIZE"])
+	require.Equal(t, "database.host", envs["BANNER_DATABASE_HOST"])
+	require.Equal(t, "auth.apiKeyCacheTtl", envs["BANNER_AUTH_API_KEY_CACHE_TTL"])
+	require.Equal(t, "auth.jwt.hmacSecret", envs["BANNER_AUTH_JWT_HMAC_SECRET"])
+	require.Equal(t, "rmq.reConnect.maxElapsedTime", envs["BANNER_RMQ_RE_CONNECT_MAX_ELAPSED_TIME"])
+	require.Equal(t, "queues.events.exchangeName", envs["BANNER_QUEUES_EVENTS_EXCHANGE_NAME"])
+
+	// Maps are only read from the config file.
+	require.NotContains(t, envs, "BANNER_RATE_LIMIT_METHODS")
+	require.NotContains(t, envs, "BANNER_LOGGER_SAMPLING")
+}
+
+func TestEnvBindingsDocumented(t *testing.T) {
+	readme, err := os.ReadFile("../../README.md")
+	require.NoError(t, err)
+
+	for _, binding := range EnvBindings(&BannerConfig{}) {
+		require.Contains(t, string(readme), "| `"+binding.Env+"` | `"+binding.Key+"` |")
+	}
+}
+
+func TestInitEnvOverrides(t *testing.T) {
+	t.Setenv("BANNER_DATABASE_HOST", "db.internal")
+	t.Setenv("BANNER_GRPC_PORT", "9082")
+	t.Setenv("BANNER_RMQ_RE_CONNECT_MAX_ELAPSED_TIME", "5m")
+	t.Setenv("BANNER_RMQ_RE_CONNECT_MULTIPLIER", "1.5")
+	t.Setenv("BANNER_RMQ_ASYNC_ENABLED", "false")
+	t.Setenv("BANNER_PUBLISHER_SINKS", "rmq,file")
+	t.Setenv("BANNER_LOGGER_LEVEL", "error")
+	t.Setenv("BANNER_FILE_PATH", "/tmp/banner") // Missing from the config file.
+
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+
+	require.Equal(t, "db.internal", conf.Database.Host)
+	require.Equal(t, 9082, conf.GRPC.Port)
+	require.Equal(t, "5m", conf.RMQ.ReConnect.MaxElapsedTime)
+	require.Equal(t, 1.5, conf.RMQ.ReConnect.Multiplier)
+	require.False(t, conf.RMQ.Async.Enabled)
+	require.Equal(t, []string{"rmq", "file"}, conf.Publisher.Sinks)
+	require.Equal(t, "error", conf.Logger.Level)
+	require.Equal(t, "/tmp/banner", conf.FilePath)
+
+	// The keys without variables keep the file values.
+	require.Equal(t, 5432, conf.Database.Port)
+	require.Equal(t, "1s", conf.RMQ.ReConnect.InitialInterval)
+}
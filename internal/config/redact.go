@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const redacted = "[REDACTED]"
+
+// secretFields are the parts of the field names whose values are secrets.
+var secretFields = []string{"password", "secret", "apikey", "token"}
+
+// Redact returns the config in its JSON form with the values of the secret fields replaced
+// and the credentials of URLs, such as publisher.webhook.url, masked.
+// Empty secrets are kept empty to show that they are not set.
+func Redact(c Configure) (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal config failed")
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(err, "unmarshal config failed")
+	}
+	redactFields(fields)
+
+	return fields, nil
+}
+
+func redactFields(fields map[string]interface{}) {
+	for name, value := range fields {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			redactFields(v)
+		case string:
+			if v != "" && isSecret(name) {
+				fields[name] = redacted
+			} else {
+				fields[name] = redactURL(v)
+			}
+		}
+	}
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range secretFields {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL masks the user info of a URL, the user name alone may be a token. Other strings are returned as is.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil || u.Host == "" {
+		return value
+	}
+	if _, ok := u.User.Password(); ok {
+		return u.Redacted()
+	}
+	u.User = url.User("xxxxx")
+	return u.String()
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// placeholderSecret is the example secret of older sample configs, known to anyone.
+	placeholderSecret = "change-me"
+	// minClickTokenSecretSize keeps the HMAC key of click tokens out of brute-force reach.
+	minClickTokenSecretSize = 16
+)
+
+// Allowed values of the enum settings. They mirror the constants of the packages using them,
+// so that config does not depend on them. TestAllowedValues keeps both in sync.
+const (
+	formatText = "text"
+	formatJSON = "json"
+
+	outputStdout = "stdout"
+	outputStderr = "stderr"
+	outputFile   = "file"
+
+	sinkRMQ     = "rmq"
+	sinkFile    = "file"
+	sinkWebhook = "webhook"
+	sinkNoop    = "noop"
+
+	encodingJSON        = "json"
+	encodingProtobuf    = "protobuf"
+	encodingCloudEvents = "cloudevents"
+
+	policyBlock      = "block"
+	policyDropOldest = "drop-oldest"
+	policyDropNew    = "drop-new"
+
+	exporterOTLP   = "otlp"
+	exporterStdout = "stdout"
+	exporterNone   = "none"
+)
+
+// logLevels are the levels accepted by parseLevel, compared case-insensitively.
+var logLevels = []string{"debug", "info", "warning", "error"}
+
+// FieldError is a problem with the value of a config key.
+type FieldError struct {
+	Path    string // Key as written in the config file, e.g. "database.port".
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError lists every problem found by Validate.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	problems := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		problems = append(problems, "  "+fieldErr.Error())
+	}
+	return fmt.Sprintf("invalid config, %d problem(s):\n%s", len(e), strings.Join(problems, "\n"))
+}
+
+// validator collects the problems instead of stopping at the first one.
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) add(path, format string, a ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) required(path, value string) {
+	if value == "" {
+		v.add(path, "must be set")
+	}
+}
+
+func (v *validator) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(path, "unknown value %q, expected one of %q", value, allowed)
+}
+
+// port checks a TCP port. Optional ports may be 0, which disables the server.
+func (v *validator) port(path string, value int, optional bool) {
+	switch {
+	case value == 0 && optional:
+	case value <= 0 || value > 65535:
+		v.add(path, "must be a port between 1 and 65535, got %d", value)
+	}
+}
+
+func (v *validator) nonNegative(path string, value float64) {
+	if value < 0 {
+		v.add(path, "must not be negative, got %v", value)
+	}
+}
+
+func (v *validator) positive(path string, value float64) {
+	if value <= 0 {
+		v.add(path, "must be positive, got %v", value)
+	}
+}
+
+// duration checks a value parsed by time.ParseDuration. Optional durations may be empty.
+func (v *validator) duration(path, value string, optional bool) {
+	if value == "" {
+		if !optional {
+			v.add(path, "must be set")
+		}
+		return
+	}
+	d, err := time.ParseDuration(value)
+	switch {
+	case err != nil:
+		v.add(path, "invalid duration %q", value)
+	case d <= 0:
+		v.add(path, "must be a positive duration, got %q", value)
+	}
+}
+
+// Validate checks the config and reports all the problems at once as a ValidationError.
+// Sections of disabled features are not checked.
+func (b *BannerConfig) Validate() error {
+	var v validator
+
+	b.validateLogger(&v)
+
+	v.required("database.host", b.Database.Host)
+	v.port("database.port", b.Database.Port, false)
+	v.required("database.dbname", b.Database.Dbname)
+	v.required("database.username", b.Database.Username)
+
+	v.port("grpc.port", b.GRPC.Port, false)
+	v.duration("grpc.healthCheckInterval", b.GRPC.HealthCheckInterval, true)
+	v.port("http.port", b.HTTP.Port, true)
+	if b.HTTP.ClickTokenSecret != "" {
+		b.validateClickTokens(&v)
+	}
+	v.port("admin.port", b.Admin.Port, true)
+
+	v.oneOf("tracing.exporter", b.Tracing.Exporter,
+		"", exporterOTLP, exporterStdout, exporterNone)
+	if b.Tracing.Exporter == exporterOTLP {
+		v.required("tracing.endpoint", b.Tracing.Endpoint)
+	}
+
+	if b.Auth.Enabled {
+		v.duration("auth.apiKeyCacheTtl", b.Auth.APIKeyCacheTTL, true)
+		// Without a JWT key only API keys are accepted, and the app checks at startup that the database has some.
+		if jwt := b.Auth.JWT; jwt.HMACSecret == "" && jwt.RSAPublicKeyFile == "" && (jwt.Issuer != "" || jwt.Audience != "") {
+			v.add("auth.jwt", "issuer and audience need auth.jwt.hmacSecret or auth.jwt.rsaPublicKeyFile")
+		}
+	}
+
+	if b.RateLimit.Enabled {
+		v.nonNegative("rateLimit.rate", b.RateLimit.Rate)
+		v.nonNegative("rateLimit.burst", float64(b.RateLimit.Burst))
+		v.duration("rateLimit.idleTimeout", b.RateLimit.IdleTimeout, true)
+		v.nonNegative("rateLimit.ip.rate", b.RateLimit.IP.Rate)
+		v.nonNegative("rateLimit.ip.burst", float64(b.RateLimit.IP.Burst))
+		for _, method := range sortedKeys(b.RateLimit.Methods) {
+			rule := b.RateLimit.Methods[method]
+			v.nonNegative("rateLimit.methods."+method+".rate", rule.Rate)
+			v.nonNegative("rateLimit.methods."+method+".burst", float64(rule.Burst))
+		}
+	}
+
+	b.validatePublisher(&v)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+func (b *BannerConfig) validateLogger(v *validator) {
+	if b.Logger.Level != "" {
+		if !slices.Contains(logLevels, strings.ToLower(b.Logger.Level)) {
+			v.add("logger.loggerLevel", "unknown value %q, expected one of %q", b.Logger.Level, logLevels)
+		}
+	}
+	v.oneOf("logger.loggerFormat", b.Logger.Format, "", formatText, formatJSON)
+	v.oneOf("logger.loggerOutput", b.Logger.Output,
+		"", outputStdout, outputStderr, outputFile)
+
+	if b.Logger.Output == outputFile {
+		file := b.Logger.File
+		v.required("logger.loggerFile.path", file.Path)
+		v.nonNegative("logger.loggerFile.maxSize", float64(file.MaxSize))
+		v.nonNegative("logger.loggerFile.maxAge", float64(file.MaxAge))
+		v.nonNegative("logger.loggerFile.maxBackups", float64(file.MaxBackups))
+		v.duration("logger.loggerFile.rotateInterval", file.RotateInterval, true)
+	}
+
+	for _, method := range sortedKeys(b.Logger.Sampling) {
+		if rate := b.Logger.Sampling[method]; rate < 0 || rate > 1 {
+			v.add("logger.loggerSampling."+method, "must be between 0 and 1, got %v", rate)
+		}
+	}
+}
+
+// validateClickTokens checks the settings of the click and beacon endpoints, enabled by a secret.
+func (b *BannerConfig) validateClickTokens(v *validator) {
+	switch secret := b.HTTP.ClickTokenSecret; {
+	case secret == placeholderSecret:
+		v.add("http.clickTokenSecret", "must be replaced by a random secret")
+	case len(secret) < minClickTokenSecretSize:
+		v.add("http.clickTokenSecret", "must be at least %d bytes long, got %d", minClickTokenSecretSize, len(secret))
+	}
+	v.duration("http.clickTokenTtl", b.HTTP.ClickTokenTTL, true)
+}
+
+func (b *BannerConfig) validatePublisher(v *validator) {
+	sinks := b.Publisher.Sinks
+	if len(sinks) == 0 {
+		sinks = []string{sinkRMQ}
+	}
+	used := make(map[string]bool, len(sinks)+1)
+	allowed := []string{sinkRMQ, sinkFile, sinkWebhook, sinkNoop}
+	for i, sink := range b.Publisher.Sinks {
+		v.oneOf(fmt.Sprintf("publisher.sinks[%d]", i), sink, allowed...)
+	}
+	for _, sink := range sinks {
+		used[sink] = true
+	}
+
+	v.oneOf("publisher.encoding", b.Publisher.Encoding,
+		"", encodingJSON, encodingProtobuf, encodingCloudEvents)
+
+	if fallback := b.Publisher.Fallback; fallback != "" {
+		switch {
+		case !used[sinkRMQ]:
+			v.add("publisher.fallback", "is only used with the %q sink", sinkRMQ)
+		case fallback == sinkRMQ:
+			v.add("publisher.fallback", "RMQ cannot be its own fallback")
+		default:
+			v.oneOf("publisher.fallback", fallback, allowed...)
+		}
+		used[fallback] = true
+	}
+
+	if used[sinkFile] {
+		v.required("publisher.file.path", b.Publisher.File.Path)
+	}
+	if used[sinkWebhook] {
+		v.required("publisher.webhook.url", b.Publisher.Webhook.URL)
+		if b.Publisher.Webhook.URL != "" {
+			if u, err := url.Parse(b.Publisher.Webhook.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				v.add("publisher.webhook.url", "must be an absolute URL")
+			}
+		}
+		v.duration("publisher.webhook.timeout", b.Publisher.Webhook.Timeout, false)
+	}
+	if used[sinkRMQ] {
+		b.validateRMQ(v)
+	}
+}
+
+func (b *BannerConfig) validateRMQ(v *validator) {
+	v.required("rmq.rabbitmqProtocol", b.RMQ.RabbitmqProtocol)
+	v.required("rmq.rabbitmqHost", b.RMQ.RabbitmqHost)
+	v.port("rmq.rabbitmqPort", b.RMQ.RabbitmqPort, false)
+
+	v.duration("rmq.reConnect.maxElapsedTime", b.RMQ.ReConnect.MaxElapsedTime, false)
+	v.duration("rmq.reConnect.initialInterval", b.RMQ.ReConnect.InitialInterval, false)
+	v.duration("rmq.reConnect.maxInterval", b.RMQ.ReConnect.MaxInterval, false)
+	if b.RMQ.ReConnect.Multiplier < 1 {
+		v.add("rmq.reConnect.multiplier", "must be at least 1, got %v", b.RMQ.ReConnect.Multiplier)
+	}
+
+	if b.RMQ.Spool.Dir != "" {
+		v.positive("rmq.spool.segmentSize", float64(b.RMQ.Spool.SegmentSize))
+		v.positive("rmq.spool.maxSize", float64(b.RMQ.Spool.MaxSize))
+	}
+
+	if async := b.RMQ.Async; async.Enabled {
+		v.positive("rmq.async.queueSize", float64(async.QueueSize))
+		v.positive("rmq.async.batchSize", float64(async.BatchSize))
+		v.duration("rmq.async.flushInterval", async.FlushInterval, false)
+		v.oneOf("rmq.async.policy", async.Policy, policyBlock, policyDropOldest, policyDropNew)
+	}
+
+	v.required("queues.events.exchangeName", b.Queues.Events.ExchangeName)
+	v.required("queues.events.exchangeType", b.Queues.Events.ExchangeType)
+}
+
+// sortedKeys makes the report of map entries stable.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
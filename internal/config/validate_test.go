@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/publisher"
+	"github.com/cronnoss/banners-rotation/internal/rmq"
+	"github.com/cronnoss/banners-rotation/internal/tracing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+	require.NoError(t, conf.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+
+	conf.Logger.Level = "verbose"
+	conf.Logger.Sampling = map[string]float64{"pickbanner": 2}
+	conf.Database.Host = ""
+	conf.GRPC.Port = 0
+	conf.HTTP.Port = 70000
+	conf.HTTP.ClickTokenSecret = "change-me"
+	conf.RMQ.ReConnect.MaxElapsedTime = "1 minute"
+	conf.RMQ.ReConnect.Multiplier = 0
+	conf.Publisher.Sinks = []string{"rmq", "kafka"}
+
+	err := conf.Validate()
+	var validationErr ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	paths := make([]string, 0, len(validationErr))
+	for _, fieldErr := range validationErr {
+		paths = append(paths, fieldErr.Path)
+	}
+	require.Equal(t, []string{
+		"logger.loggerLevel",
+		"logger.loggerSampling.pickbanner",
+		"database.host",
+		"grpc.port",
+		"http.port",
+		"http.clickTokenSecret",
+		"publisher.sinks[1]",
+		"rmq.reConnect.maxElapsedTime",
+		"rmq.reConnect.multiplier",
+	}, paths)
+	require.Contains(t, err.Error(), "invalid config, 9 problem(s)")
+	require.Contains(t, err.Error(), `rmq.reConnect.maxElapsedTime: invalid duration "1 minute"`)
+}
+
+func TestValidateDisabledSections(t *testing.T) {
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+
+	// Sections of disabled features and unused sinks are not checked.
+	conf.RateLimit.Enabled = false
+	conf.RateLimit.Rate = -1
+	conf.Publisher.Sinks = []string{"noop"}
+	conf.RMQ.RabbitmqHost = ""
+	conf.Publisher.Webhook.URL = ""
+	require.NoError(t, conf.Validate())
+
+	conf.Publisher.Sinks = []string{"webhook"}
+	require.EqualError(t, conf.Validate(), "invalid config, 1 problem(s):\n  publisher.webhook.url: must be set")
+}
+
+func TestValidateAuth(t *testing.T) {
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+
+	conf.Auth.Enabled = true
+	require.NoError(t, conf.Validate())
+
+	conf.Auth.JWT.Issuer = "issuer"
+	require.EqualError(t, conf.Validate(), "invalid config, 1 problem(s):\n  "+
+		"auth.jwt: issuer and audience need auth.jwt.hmacSecret or auth.jwt.rsaPublicKeyFile")
+
+	conf.Auth.JWT.HMACSecret = "secret"
+	require.NoError(t, conf.Validate())
+}
+
+func TestValidateClickTokenSecret(t *testing.T) {
+	conf := &BannerConfig{}
+	require.NoError(t, conf.Init("../../configs/banner_config.yaml"))
+
+	// The click endpoint is disabled by default.
+	require.Empty(t, conf.HTTP.ClickTokenSecret)
+	require.NoError(t, conf.Validate())
+
+	conf.HTTP.ClickTokenSecret = "change-me"
+	require.EqualError(t, conf.Validate(),
+		"invalid config, 1 problem(s):\n  http.clickTokenSecret: must be replaced by a random secret")
+
+	conf.HTTP.ClickTokenSecret = "short"
+	require.EqualError(t, conf.Validate(),
+		"invalid config, 1 problem(s):\n  http.clickTokenSecret: must be at least 16 bytes long, got 5")
+
+	conf.HTTP.ClickTokenSecret = "0123456789abcdef"
+	require.NoError(t, conf.Validate())
+}
+
+func TestAllowedValues(t *testing.T) {
+	for _, level := range logLevels {
+		_, err := logger.ParseLevel(level)
+		require.NoError(t, err, level)
+	}
+
+	require.Equal(t, logger.FormatText, formatText)
+	require.Equal(t, logger.FormatJSON, formatJSON)
+	require.Equal(t, logger.OutputStdout, outputStdout)
+	require.Equal(t, logger.OutputStderr, outputStderr)
+	require.Equal(t, logger.OutputFile, outputFile)
+
+	require.Equal(t, publisher.SinkRMQ, sinkRMQ)
+	require.Equal(t, publisher.SinkFile, sinkFile)
+	require.Equal(t, publisher.SinkWebhook, sinkWebhook)
+	require.Equal(t, publisher.SinkNoop, sinkNoop)
+	require.Equal(t, publisher.EncodingJSON, encodingJSON)
+	require.Equal(t, publisher.EncodingProtobuf, encodingProtobuf)
+	require.Equal(t, publisher.EncodingCloudEvents, encodingCloudEvents)
+
+	require.Equal(t, rmq.PolicyBlock, policyBlock)
+	require.Equal(t, rmq.PolicyDropOldest, policyDropOldest)
+	require.Equal(t, rmq.PolicyDropNew, policyDropNew)
+
+	require.Equal(t, tracing.ExporterOTLP, exporterOTLP)
+	require.Equal(t, tracing.ExporterStdout, exporterStdout)
+	require.Equal(t, tracing.ExporterNone, exporterNone)
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatText = "text" // LEVEL [time] message key=value ...
+	FormatJSON = "json" // {"time": ..., "level": ..., "msg": ..., "key": value, ...}
+)
+
+const badKey = "!BADKEY"
+
+func formatText(now time.Time, level LogLevel, message string, fields []any) []byte {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s [%s] ", level, now.Format("2006-01-02 15:04:05")))
+	b.WriteString(strings.TrimSuffix(message, "\n"))
+	forEachField(fields, func(key string, value any) {
+		b.WriteString(" " + key + "=" + textValue(value))
+	})
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+func formatJSON(now time.Time, level LogLevel, message string, fields []any) []byte {
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	writeJSONValue(&b, now.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSONValue(&b, string(level))
+	b.WriteString(`,"msg":`)
+	writeJSONValue(&b, strings.TrimSuffix(message, "\n"))
+	forEachField(fields, func(key string, value any) {
+		b.WriteString(",")
+		writeJSONValue(&b, key)
+		b.WriteString(":")
+		writeJSONValue(&b, jsonValue(value))
+	})
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// forEachField calls f for every key-value pair. A key without a value is reported as the value of badKey.
+func forEachField(fields []any, f func(key string, value any)) {
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 == len(fields) {
+			f(badKey, fields[i])
+			return
+		}
+		key, ok := fields[i].(string)
+		if !ok {
+			key = fmt.Sprint(fields[i])
+		}
+		f(key, fields[i+1])
+	}
+}
+
+// textValue quotes the values that would not be read back as one value.
+func textValue(value any) string {
+	text := fmt.Sprint(value)
+	if text == "" || strings.ContainsAny(text, " =\"\t\n") {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+// jsonValue returns the value to encode. Errors and values with a String method, such as
+// time.Duration and the gRPC codes, are encoded as their text instead of their fields.
+func jsonValue(value any) any {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case json.Marshaler:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+func writeJSONValue(b *strings.Builder, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(data)
+}
@@ -1,10 +1,14 @@
 package logger
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/requestid"
 )
 
 type LogLevel string
@@ -17,68 +21,157 @@ const (
 )
 
 type Logger struct {
-	level   LogLevel
+	level   *atomic.Value // LogLevel, it can be changed at runtime by SetLevel.
 	writeTo io.Writer
+	format  string
+	fields  []any // Key-value pairs added by With.
 }
 
+// New returns a logger writing in the text format.
 func New(level string, writeTo io.Writer) *Logger {
+	l, _ := NewWithFormat(level, FormatText, writeTo)
+	return l
+}
+
+// NewWithFormat returns a logger writing in the given format, FormatText or FormatJSON.
+func NewWithFormat(level, format string, writeTo io.Writer) (*Logger, error) {
+	switch format {
+	case FormatText, FormatJSON:
+	case "":
+		format = FormatText
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
 	lev := logLevelFromString(level)
-	return &Logger{lev, writeTo}
+	l := &Logger{level: &atomic.Value{}, writeTo: writeTo, format: format}
+	l.level.Store(lev)
+	return l, nil
+}
+
+// With returns a logger adding the key-value pairs to every message. The level is shared with l.
+func (l Logger) With(keysAndValues ...any) *Logger {
+	fields := make([]any, 0, len(l.fields)+len(keysAndValues))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keysAndValues...)
+	l.fields = fields
+	return &l
 }
 
 func logLevelFromString(level string) LogLevel {
-	level = strings.ToLower(level)
-	switch level {
+	lev, err := ParseLevel(level)
+	if err != nil {
+		return Info
+	}
+	return lev
+}
+
+// ParseLevel returns the level named by level, case-insensitively.
+func ParseLevel(level string) (LogLevel, error) {
+	switch strings.ToLower(level) {
 	case "error":
-		return Error
+		return Error, nil
 	case "warning":
-		return Warning
+		return Warning, nil
 	case "info":
-		return Info
+		return Info, nil
 	case "debug":
-		return Debug
+		return Debug, nil
 	default:
-		return Info
+		return "", fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// Level returns the current level.
+func (l Logger) Level() LogLevel {
+	return l.level.Load().(LogLevel)
+}
+
+// SetLevel changes the level of the logger and of its copies. It is safe for concurrent use.
+func (l Logger) SetLevel(level string) error {
+	lev, err := ParseLevel(level)
+	if err != nil {
+		return err
 	}
+	l.level.Store(lev)
+	return nil
 }
 
 func (l Logger) msg(level LogLevel, template string, a ...any) {
-	var buildedString strings.Builder
-	buildedString.WriteString(fmt.Sprintf("%s [%s] ", level, time.Now().UTC().Format("2006-01-02 15:04:05")))
-	buildedString.WriteString(fmt.Sprintf(template, a...))
-	if !strings.HasSuffix(template, "\n") {
-		buildedString.WriteString("\n")
+	now := time.Now().UTC()
+	message := fmt.Sprintf(template, a...)
+
+	var line []byte
+	if l.format == FormatJSON {
+		line = formatJSON(now, level, message, l.fields)
+	} else {
+		line = formatText(now, level, message, l.fields)
 	}
-	_, err := l.writeTo.Write([]byte(buildedString.String()))
+	_, err := l.writeTo.Write(line)
 	if err != nil {
 		fmt.Printf("error writing to log: %s\n", err)
 	}
 }
 
+// severity orders the levels: a message is logged if its level is at least as severe as the logger level.
+var severity = map[LogLevel]int{Debug: 0, Info: 1, Warning: 2, Error: 3}
+
+// Enabled reports whether the messages of the level are logged.
+func (l Logger) Enabled(level LogLevel) bool {
+	return severity[level] >= severity[l.Level()]
+}
+
 func (l Logger) Debug(template string, a ...any) {
-	if l.level == Debug {
+	if l.Enabled(Debug) {
 		l.msg(Debug, template, a...)
 	}
 }
 
 func (l Logger) Info(template string, a ...any) {
-	if l.level == Info || l.level == Debug {
+	if l.Enabled(Info) {
 		l.msg(Info, template, a...)
 	}
 }
 
 func (l Logger) Warning(template string, a ...any) {
-	if l.level == Warning || l.level == Info || l.level == Debug {
+	if l.Enabled(Warning) {
 		l.msg(Warning, template, a...)
 	}
 }
 
 func (l Logger) Error(template string, a ...any) {
-	if l.level == Error || l.level == Warning || l.level == Info || l.level == Debug {
+	if l.Enabled(Error) {
 		l.msg(Error, template, a...)
 	}
 }
 
+// DebugContext logs like Debug, adding the request ID stored in ctx.
+func (l Logger) DebugContext(ctx context.Context, template string, a ...any) {
+	l.withContext(ctx).Debug(template, a...)
+}
+
+// InfoContext logs like Info, adding the request ID stored in ctx.
+func (l Logger) InfoContext(ctx context.Context, template string, a ...any) {
+	l.withContext(ctx).Info(template, a...)
+}
+
+// WarningContext logs like Warning, adding the request ID stored in ctx.
+func (l Logger) WarningContext(ctx context.Context, template string, a ...any) {
+	l.withContext(ctx).Warning(template, a...)
+}
+
+// ErrorContext logs like Error, adding the request ID stored in ctx.
+func (l Logger) ErrorContext(ctx context.Context, template string, a ...any) {
+	l.withContext(ctx).Error(template, a...)
+}
+
+func (l Logger) withContext(ctx context.Context) *Logger {
+	if id := requestid.FromContext(ctx); id != "" {
+		return l.With("request_id", id)
+	}
+	return &l
+}
+
 func (l Logger) Log(template string, a ...any) {
-	l.msg(l.level, template, a...)
+	l.msg(l.Level(), template, a...)
 }
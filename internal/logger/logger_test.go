@@ -2,6 +2,8 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -9,7 +11,9 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/cronnoss/banners-rotation/internal/requestid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -154,3 +158,70 @@ func TestFatalf(t *testing.T) {
 	}
 	t.Fatalf("process ran with err %v, want exit status 1", err)
 }
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New("error", &buf)
+	copied := *logger
+
+	logger.Info("hidden")
+	require.Empty(t, buf.String())
+
+	require.NoError(t, logger.SetLevel("DEBUG"))
+	require.Equal(t, LogLevel(Debug), copied.Level())
+	copied.Debug("shown")
+	require.Contains(t, buf.String(), "DEBUG [")
+
+	require.Error(t, logger.SetLevel("verbose"))
+	require.Equal(t, LogLevel(Debug), logger.Level())
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewWithFormat("info", FormatJSON, &buf)
+	require.NoError(t, err)
+
+	logger.With("method", "PickBanner", "latency_ms", 1.5).
+		With("status", time.Second, "err", errors.New("failed"), "odd").
+		Info("Finished %s", "call")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "INFO", line["level"])
+	require.Equal(t, "Finished call", line["msg"])
+	require.Equal(t, "PickBanner", line["method"])
+	require.Equal(t, 1.5, line["latency_ms"])
+	require.Equal(t, "1s", line["status"])
+	require.Equal(t, "failed", line["err"])
+	require.Equal(t, "odd", line["!BADKEY"])
+	require.NotEmpty(t, line["time"])
+
+	// Fields of a derived logger do not leak into the parent.
+	buf.Reset()
+	logger.Info("plain")
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.NotContains(t, buf.String(), "method")
+
+	_, err = NewWithFormat("info", "xml", &buf)
+	require.Error(t, err)
+}
+
+func TestTextFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New("info", &buf)
+
+	logger.With("method", "PickBanner", "peer", "", "user_agent", "grpc-go/1.60.1 x").Info("Finished call")
+	require.Contains(t, buf.String(), `] Finished call method=PickBanner peer="" user_agent="grpc-go/1.60.1 x"`+"\n")
+}
+
+func TestContextMethods(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New("info", &buf)
+
+	logger.InfoContext(requestid.NewContext(context.Background(), "req-1"), "Received request")
+	require.Contains(t, buf.String(), "Received request request_id=req-1\n")
+
+	buf.Reset()
+	logger.ErrorContext(context.Background(), "No request")
+	require.NotContains(t, buf.String(), "request_id")
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// FileOutput writes the log to a file rotated by size and, optionally, at a fixed interval.
+// Rotated files are named after the rotation time, only the newest maxBackups files younger
+// than maxAgeDays are kept. Zero maxAgeDays and maxBackups mean no limit, zero maxSizeMB means 100.
+type FileOutput struct {
+	file *lumberjack.Logger
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func NewFileOutput(
+	path string,
+	maxSizeMB, maxAgeDays, maxBackups int,
+	compress bool,
+	rotateInterval time.Duration,
+) *FileOutput {
+	f := &FileOutput{
+		file: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    maxSizeMB,
+			MaxAge:     maxAgeDays,
+			MaxBackups: maxBackups,
+			Compress:   compress,
+		},
+		stop: make(chan struct{}),
+	}
+	if rotateInterval > 0 {
+		go f.rotateEvery(rotateInterval)
+	}
+	return f
+}
+
+func (f *FileOutput) Write(p []byte) (int, error) {
+	return f.file.Write(p)
+}
+
+// Reopen closes the file, the next write opens it again. After an external tool such as logrotate
+// has moved the file, the log goes to a new file at the configured path.
+func (f *FileOutput) Reopen() error {
+	return f.file.Close()
+}
+
+// Rotate moves the current file aside and starts a new one.
+func (f *FileOutput) Rotate() error {
+	return f.file.Rotate()
+}
+
+func (f *FileOutput) Close() error {
+	f.stopOnce.Do(func() { close(f.stop) })
+	return f.file.Close()
+}
+
+func (f *FileOutput) rotateEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			if err := f.Rotate(); err != nil {
+				fmt.Fprintf(os.Stderr, "error rotating log file: %s\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "banner.log")
+	out := NewFileOutput(path, 1, 0, 0, false, 0)
+	defer out.Close()
+
+	l := New("info", out)
+	l.Info("first")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "first")
+
+	t.Run("reopen after move", func(t *testing.T) {
+		moved := filepath.Join(dir, "banner.log.1")
+		require.NoError(t, os.Rename(path, moved))
+		require.NoError(t, out.Reopen())
+		l.Info("second")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "second")
+		require.NotContains(t, string(data), "first")
+
+		data, err = os.ReadFile(moved)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), "second")
+	})
+
+	t.Run("rotate", func(t *testing.T) {
+		require.NoError(t, out.Rotate())
+		l.Info("third")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "third")
+		require.NotContains(t, string(data), "second")
+
+		backups, err := filepath.Glob(filepath.Join(dir, "banner-*.log"))
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+	})
+}
@@ -0,0 +1,13 @@
+package logger
+
+import "net/url"
+
+// RedactURI returns uri with the password masked, so that connection strings can be logged.
+func RedactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		// The parse error quotes the URI, the password included.
+		return "<invalid URI>"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/requestid"
+)
+
+var _ interfaces.Logger = (*SlogLogger)(nil)
+
+// SlogLogger implements interfaces.Logger on top of a slog.Handler, so that the service can log
+// through a slog-based logging stack. The level filtering and the output are the handler's.
+type SlogLogger struct {
+	handler slog.Handler
+}
+
+func NewSlogLogger(handler slog.Handler) *SlogLogger {
+	return &SlogLogger{handler: handler}
+}
+
+func (l *SlogLogger) Debug(template string, a ...any) {
+	l.log(context.Background(), slog.LevelDebug, template, a...)
+}
+
+func (l *SlogLogger) Info(template string, a ...any) {
+	l.log(context.Background(), slog.LevelInfo, template, a...)
+}
+
+func (l *SlogLogger) Warning(template string, a ...any) {
+	l.log(context.Background(), slog.LevelWarn, template, a...)
+}
+
+func (l *SlogLogger) Error(template string, a ...any) {
+	l.log(context.Background(), slog.LevelError, template, a...)
+}
+
+func (l *SlogLogger) DebugContext(ctx context.Context, template string, a ...any) {
+	l.log(ctx, slog.LevelDebug, template, a...)
+}
+
+func (l *SlogLogger) InfoContext(ctx context.Context, template string, a ...any) {
+	l.log(ctx, slog.LevelInfo, template, a...)
+}
+
+func (l *SlogLogger) WarningContext(ctx context.Context, template string, a ...any) {
+	l.log(ctx, slog.LevelWarn, template, a...)
+}
+
+func (l *SlogLogger) ErrorContext(ctx context.Context, template string, a ...any) {
+	l.log(ctx, slog.LevelError, template, a...)
+}
+
+// log formats the message only if the handler is enabled for the level.
+// The request ID stored in ctx is added as the request_id attribute.
+func (l *SlogLogger) log(ctx context.Context, level slog.Level, template string, a ...any) {
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+	record := slog.NewRecord(time.Now(), level, fmt.Sprintf(template, a...), 0)
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	_ = l.handler.Handle(ctx, record)
+}
+
+// SlogHandler is a slog.Handler writing through a Logger, so that library code using slog
+// follows the level and the output format of the service. Attributes become fields of the message,
+// the attributes of groups are prefixed with the group name.
+type SlogHandler struct {
+	logger *Logger
+	group  string // Prefix of the keys, with a trailing dot.
+}
+
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(levelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	fields := make([]any, 0, 2*record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, h.group, attr)
+		return true
+	})
+	h.logger.withContext(ctx).With(fields...).msg(levelFromSlog(record.Level), "%s", record.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]any, 0, 2*len(attrs))
+	for _, attr := range attrs {
+		fields = appendAttr(fields, h.group, attr)
+	}
+	return &SlogHandler{logger: h.logger.With(fields...), group: h.group}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr appends the attribute as key-value pairs, flattening the groups.
+func appendAttr(fields []any, prefix string, attr slog.Attr) []any {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range value.Group() {
+			fields = appendAttr(fields, prefix, a)
+		}
+		return fields
+	}
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	return append(fields, prefix+attr.Key, value.Any())
+}
+
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return Debug
+	case level < slog.LevelWarn:
+		return Info
+	case level < slog.LevelError:
+		return Warning
+	default:
+		return Error
+	}
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/requestid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlogLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	logger.Info("hidden %d", 1)
+	require.Empty(t, buf.String())
+
+	logger.WarningContext(requestid.NewContext(context.Background(), "req-1"), "RMQ publish failed: %v", "timeout")
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "WARN", line["level"])
+	require.Equal(t, "RMQ publish failed: timeout", line["msg"])
+	require.Equal(t, "req-1", line["request_id"])
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New("info", &buf)
+	slogger := slog.New(NewSlogHandler(logger)).With("component", "pgx").WithGroup("query")
+
+	slogger.Debug("hidden")
+	require.Empty(t, buf.String())
+
+	slogger.InfoContext(requestid.NewContext(context.Background(), "req-1"), "Query done",
+		"rows", 3, slog.Group("conn", "pid", 42))
+	require.Contains(t, buf.String(), "INFO [")
+	require.Contains(t, buf.String(), "Query done component=pgx request_id=req-1 query.rows=3 query.conn.pid=42\n")
+
+	// The level of the logger applies to slog.
+	buf.Reset()
+	require.NoError(t, logger.SetLevel("debug"))
+	slogger.Debug("shown")
+	require.Contains(t, buf.String(), "DEBUG [")
+
+	buf.Reset()
+	slogger.Warn("warned")
+	require.Contains(t, buf.String(), "WARNING [")
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "banner"
+
+// Registry holds the metrics of the service, served by Handler.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	RPCRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Number of gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+
+	RPCDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of gRPC requests by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	Picks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "picks_total",
+		Help:      "Number of picked banners by slot and banner.",
+	}, []string{"slot_id", "banner_id"})
+
+	Clicks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clicks_total",
+		Help:      "Number of clicks by slot and banner.",
+	}, []string{"slot_id", "banner_id"})
+
+	DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of storage queries by query.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"query"})
+
+	DBQueryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of failed storage queries by query.",
+	}, []string{"query"})
+
+	RMQPublishes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rmq",
+		Name:      "publishes_total",
+		Help:      "Number of messages published to RabbitMQ by result (success, failure).",
+	}, []string{"result"})
+
+	RMQReconnects = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rmq",
+		Name:      "reconnects_total",
+		Help:      "Number of successful reconnections to RabbitMQ.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterGaugeFunc registers a gauge whose value is computed by f on every scrape.
+// The name is prefixed with the namespace and the subsystem.
+func RegisterGaugeFunc(subsystem, name, help string, f func() float64) error {
+	return Registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, f))
+}
+
+// RegisterCounterFunc registers a counter whose value is computed by f on every scrape.
+// The name is prefixed with the namespace and the subsystem.
+func RegisterCounterFunc(subsystem, name, help string, f func() float64) error {
+	return Registry.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, f))
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,161 @@
+package publisher
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Encodings of the published events.
+const (
+	EncodingJSON        = "json"        // Plain JSON of storage.Notification, no envelope.
+	EncodingProtobuf    = "protobuf"    // Binary pb.Event, CloudEvents attributes in headers.
+	EncodingCloudEvents = "cloudevents" // CloudEvents 1.0 structured JSON envelope.
+)
+
+const (
+	// SchemaVersion is the version of the pb.Event schema. Bump it on incompatible changes.
+	SchemaVersion = "1.0"
+
+	cloudEventsSpecVersion = "1.0"
+	eventTypePrefix        = "banners-rotation."
+	defaultSource          = "/banners-rotation"
+)
+
+// Message is an encoded notification ready to be sent by a sink.
+// Headers hold the CloudEvents attributes; every sink adds its own binding prefix.
+type Message struct {
+	ContentType string
+	Body        []byte
+	Headers     map[string]string
+}
+
+type Encoder interface {
+	Encode(notification storage.Notification) (*Message, error)
+}
+
+func NewEncoder(encoding, source string) (Encoder, error) {
+	if source == "" {
+		source = defaultSource
+	}
+	switch encoding {
+	case "", EncodingJSON:
+		return jsonEncoder{}, nil
+	case EncodingProtobuf:
+		return protobufEncoder{source: source}, nil
+	case EncodingCloudEvents:
+		return cloudEventsEncoder{source: source}, nil
+	default:
+		return nil, errors.Errorf("unknown event encoding %q", encoding)
+	}
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(notification storage.Notification) (*Message, error) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return nil, errors.Wrap(err, "json marshal fail")
+	}
+	return &Message{ContentType: "application/json", Body: body}, nil
+}
+
+type protobufEncoder struct {
+	source string
+}
+
+func (e protobufEncoder) Encode(notification storage.Notification) (*Message, error) {
+	body, err := proto.Marshal(NewEvent(notification))
+	if err != nil {
+		return nil, errors.Wrap(err, "protobuf marshal fail")
+	}
+
+	attrs, err := newAttributes(e.source, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		ContentType: "application/protobuf",
+		Body:        body,
+		Headers: map[string]string{
+			"specversion":     attrs.SpecVersion,
+			"id":              attrs.ID,
+			"source":          attrs.Source,
+			"type":            attrs.Type,
+			"time":            attrs.Time,
+			"datacontenttype": "application/protobuf",
+			"schemaversion":   attrs.SchemaVersion,
+		},
+	}, nil
+}
+
+type cloudEventsEncoder struct {
+	source string
+}
+
+type cloudEvent struct {
+	attributes
+	DataContentType string               `json:"datacontenttype"`
+	Data            storage.Notification `json:"data"`
+}
+
+func (e cloudEventsEncoder) Encode(notification storage.Notification) (*Message, error) {
+	attrs, err := newAttributes(e.source, notification)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(cloudEvent{
+		attributes:      attrs,
+		DataContentType: "application/json",
+		Data:            notification,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudevents marshal fail")
+	}
+
+	return &Message{ContentType: "application/cloudevents+json", Body: body}, nil
+}
+
+// attributes are the CloudEvents 1.0 context attributes of an event.
+type attributes struct {
+	SpecVersion   string `json:"specversion"`
+	ID            string `json:"id"`
+	Source        string `json:"source"`
+	Type          string `json:"type"`
+	Time          string `json:"time"`
+	SchemaVersion string `json:"schemaversion"`
+}
+
+func newAttributes(source string, notification storage.Notification) (attributes, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return attributes{}, errors.Wrap(err, "event id generation fail")
+	}
+	return attributes{
+		SpecVersion:   cloudEventsSpecVersion,
+		ID:            id.String(),
+		Source:        source,
+		Type:          eventTypePrefix + notification.TypeEvent,
+		Time:          notification.DateTime.UTC().Format(time.RFC3339Nano),
+		SchemaVersion: SchemaVersion,
+	}, nil
+}
+
+// NewEvent converts the notification to the Event message defined in api/Event.proto.
+func NewEvent(notification storage.Notification) *pb.Event {
+	return &pb.Event{
+		TypeEvent:   notification.TypeEvent,
+		SlotId:      int32(notification.SlotID),
+		BannerId:    int32(notification.BannerID),
+		UsergroupId: int32(notification.UsergroupID),
+		DateTime:    timestamppb.New(notification.DateTime),
+	}
+}
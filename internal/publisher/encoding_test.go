@@ -0,0 +1,78 @@
+package publisher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	enc, err := NewEncoder(EncodingJSON, "")
+	require.NoError(t, err)
+
+	msg, err := enc.Encode(testNotification())
+	require.NoError(t, err)
+	require.Equal(t, "application/json", msg.ContentType)
+	require.Empty(t, msg.Headers)
+	require.JSONEq(t,
+		`{"type_event":"click","slot_id":1,"banner_id":2,"usergroup_id":3,"date_time":"2024-01-18T07:36:31Z"}`,
+		string(msg.Body),
+	)
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	enc, err := NewEncoder(EncodingProtobuf, "/test")
+	require.NoError(t, err)
+
+	msg, err := enc.Encode(testNotification())
+	require.NoError(t, err)
+	require.Equal(t, "application/protobuf", msg.ContentType)
+
+	var event pb.Event
+	require.NoError(t, proto.Unmarshal(msg.Body, &event))
+	require.Equal(t, "click", event.GetTypeEvent())
+	require.Equal(t, int32(1), event.GetSlotId())
+	require.Equal(t, int32(2), event.GetBannerId())
+	require.Equal(t, int32(3), event.GetUsergroupId())
+	require.True(t, event.GetDateTime().AsTime().Equal(testNotification().DateTime))
+
+	require.Equal(t, "1.0", msg.Headers["specversion"])
+	require.Equal(t, "/test", msg.Headers["source"])
+	require.Equal(t, "banners-rotation.click", msg.Headers["type"])
+	require.Equal(t, "2024-01-18T07:36:31Z", msg.Headers["time"])
+	require.Equal(t, SchemaVersion, msg.Headers["schemaversion"])
+	require.NotEmpty(t, msg.Headers["id"])
+}
+
+func TestCloudEventsEncoder(t *testing.T) {
+	enc, err := NewEncoder(EncodingCloudEvents, "")
+	require.NoError(t, err)
+
+	first, err := enc.Encode(testNotification())
+	require.NoError(t, err)
+	second, err := enc.Encode(testNotification())
+	require.NoError(t, err)
+	require.Equal(t, "application/cloudevents+json", first.ContentType)
+
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal(first.Body, &envelope))
+	require.Equal(t, "1.0", envelope["specversion"])
+	require.Equal(t, defaultSource, envelope["source"])
+	require.Equal(t, "banners-rotation.click", envelope["type"])
+	require.Equal(t, "2024-01-18T07:36:31Z", envelope["time"])
+	require.Equal(t, SchemaVersion, envelope["schemaversion"])
+	require.Equal(t, "application/json", envelope["datacontenttype"])
+	require.Equal(t, "click", envelope["data"].(map[string]any)["type_event"])
+
+	var secondEnvelope map[string]any
+	require.NoError(t, json.Unmarshal(second.Body, &secondEnvelope))
+	require.NotEqual(t, envelope["id"], secondEnvelope["id"])
+}
+
+func TestUnknownEncoding(t *testing.T) {
+	_, err := NewEncoder("xml", "")
+	require.Error(t, err)
+}
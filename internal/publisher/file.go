@@ -0,0 +1,50 @@
+package publisher
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/pkg/errors"
+)
+
+var _ interfaces.EventPublisher = (*File)(nil)
+
+// File appends notifications to a file as newline-delimited JSON.
+// The encoder must produce single-line JSON, so the protobuf encoding cannot be used here.
+type File struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder Encoder
+}
+
+func NewFile(path string, encoder Encoder) (*File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "open events file fail (%s)", path)
+	}
+	return &File{file: f, encoder: encoder}, nil
+}
+
+func (f *File) Publish(ctx context.Context, notification storage.Notification) error {
+	_ = ctx
+	encoded, err := f.encoder.Encode(notification)
+	if err != nil {
+		return errors.Wrap(err, "encode notification fail")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.file.Write(append(encoded.Body, '\n')); err != nil {
+		return errors.Wrap(err, "write events file fail")
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,26 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+)
+
+var _ interfaces.EventPublisher = (*Noop)(nil)
+
+// Noop discards every notification.
+type Noop struct{}
+
+func NewNoop() *Noop {
+	return &Noop{}
+}
+
+func (n *Noop) Publish(ctx context.Context, notification storage.Notification) error {
+	_, _ = ctx, notification
+	return nil
+}
+
+func (n *Noop) Close() error {
+	return nil
+}
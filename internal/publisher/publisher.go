@@ -0,0 +1,67 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+)
+
+// Names of the sinks that can be listed in the publisher configuration.
+const (
+	SinkRMQ     = "rmq"
+	SinkFile    = "file"
+	SinkWebhook = "webhook"
+	SinkNoop    = "noop"
+)
+
+var _ interfaces.EventPublisher = (*Multi)(nil)
+
+// HealthChecker is implemented by publishers that depend on an external service.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Multi fans out every notification to several publishers.
+type Multi struct {
+	publishers []interfaces.EventPublisher
+}
+
+func NewMulti(publishers ...interfaces.EventPublisher) *Multi {
+	return &Multi{publishers: publishers}
+}
+
+// Publish sends the notification to every publisher, even if some of them fail.
+func (m *Multi) Publish(ctx context.Context, notification storage.Notification) error {
+	var errs []error
+	for _, p := range m.publishers {
+		if err := p.Publish(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Multi) Close() error {
+	var errs []error
+	for _, p := range m.publishers {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckHealth checks every publisher that depends on an external service.
+func (m *Multi) CheckHealth(ctx context.Context) error {
+	var errs []error
+	for _, p := range m.publishers {
+		if checker, ok := p.(HealthChecker); ok {
+			if err := checker.CheckHealth(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
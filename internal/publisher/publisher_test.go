@@ -0,0 +1,118 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPublisher struct {
+	calls int
+}
+
+func (f *failingPublisher) Publish(ctx context.Context, notification storage.Notification) error {
+	_, _ = ctx, notification
+	f.calls++
+	return errors.New("publish failed")
+}
+
+func (f *failingPublisher) Close() error {
+	return nil
+}
+
+func testNotification() storage.Notification {
+	return storage.Notification{
+		TypeEvent:   "click",
+		SlotID:      1,
+		BannerID:    2,
+		UsergroupID: 3,
+		DateTime:    time.Date(2024, 1, 18, 7, 36, 31, 0, time.UTC),
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	p, err := NewFile(path, jsonEncoder{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, p.Publish(ctx, testNotification()))
+	require.NoError(t, p.Publish(ctx, testNotification()))
+	require.NoError(t, p.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var got storage.Notification
+		require.NoError(t, json.Unmarshal([]byte(line), &got))
+		require.Equal(t, testNotification(), got)
+	}
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p, err := NewWebhook(srv.URL, "1s", jsonEncoder{})
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Publish(context.Background(), testNotification()))
+
+	var got storage.Notification
+	require.NoError(t, json.Unmarshal(body, &got))
+	require.Equal(t, testNotification(), got)
+}
+
+func TestWebhookPublisherErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	p, err := NewWebhook(srv.URL, "1s", jsonEncoder{})
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.Error(t, p.Publish(context.Background(), testNotification()))
+}
+
+func TestMultiPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	file, err := NewFile(path, jsonEncoder{})
+	require.NoError(t, err)
+
+	failing := &failingPublisher{}
+	m := NewMulti(failing, file, NewNoop())
+
+	err = m.Publish(context.Background(), testNotification())
+	require.Error(t, err)
+	require.Equal(t, 1, failing.calls)
+	require.NoError(t, m.Close())
+
+	// The failing sink must not prevent delivery to the others.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, data)
+}
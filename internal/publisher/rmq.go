@@ -0,0 +1,86 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/rmq"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+)
+
+var _ interfaces.EventPublisher = (*Rmq)(nil)
+
+// Rmq publishes notifications to a RabbitMQ exchange.
+type Rmq struct {
+	mq       *rmq.Rmq
+	async    *rmq.AsyncPublisher
+	sender   rmq.Publisher
+	encoder  Encoder
+	fallback interfaces.EventPublisher
+}
+
+func NewRmq(mq *rmq.Rmq, encoder Encoder) *Rmq {
+	return &Rmq{mq: mq, sender: mq, encoder: encoder}
+}
+
+// SetAsync makes Publish queue the messages to the async publisher instead of sending them right away.
+func (r *Rmq) SetAsync(async *rmq.AsyncPublisher) {
+	r.async = async
+	r.sender = async
+}
+
+// SetFallback sets the publisher used while the RMQ reconnection backoff is exhausted.
+func (r *Rmq) SetFallback(fallback interfaces.EventPublisher) {
+	r.fallback = fallback
+}
+
+func (r *Rmq) Publish(ctx context.Context, notification storage.Notification) error {
+	if r.fallback != nil && r.mq.State() == rmq.StateDisconnected {
+		return r.fallback.Publish(ctx, notification)
+	}
+
+	encoded, err := r.encoder.Encode(notification)
+	if err != nil {
+		return errors.Wrap(err, "encode notification fail")
+	}
+
+	msg := amqp.Publishing{
+		ContentType: encoded.ContentType,
+		Body:        encoded.Body,
+		Headers:     make(amqp.Table, len(encoded.Headers)),
+	}
+	for k, v := range encoded.Headers {
+		// CloudEvents AMQP protocol binding.
+		msg.Headers["cloudEvents:"+k] = v
+	}
+	// The trace context travels with the message through the async queue and the spool.
+	otel.GetTextMapPropagator().Inject(ctx, rmq.HeadersCarrier(msg.Headers))
+
+	return r.sender.Publish(msg)
+}
+
+// CheckHealth fails while the RMQ connection is closed.
+func (r *Rmq) CheckHealth(_ context.Context) error {
+	if r.mq.IsClosed() {
+		return errors.Errorf("rmq connection is closed (%s)", r.mq.State())
+	}
+	return nil
+}
+
+func (r *Rmq) Close() error {
+	if r.async != nil {
+		// Flush the queued messages while the connection is still open.
+		if err := r.async.Close(); err != nil {
+			return err
+		}
+	}
+	if r.fallback != nil {
+		if err := r.fallback.Close(); err != nil {
+			return err
+		}
+	}
+	return r.mq.Close()
+}
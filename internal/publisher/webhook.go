@@ -0,0 +1,73 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/pkg/errors"
+)
+
+var _ interfaces.EventPublisher = (*Webhook)(nil)
+
+// Webhook POSTs every notification to an HTTP endpoint.
+type Webhook struct {
+	url     string
+	client  *http.Client
+	encoder Encoder
+}
+
+func NewWebhook(url, timeout string, encoder Encoder) (*Webhook, error) {
+	if url == "" {
+		return nil, errors.New("webhook url is empty")
+	}
+
+	timeoutDur, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "webhook timeout parsing fail (%s)", timeout)
+	}
+
+	return &Webhook{
+		url:     url,
+		client:  &http.Client{Timeout: timeoutDur},
+		encoder: encoder,
+	}, nil
+}
+
+func (w *Webhook) Publish(ctx context.Context, notification storage.Notification) error {
+	encoded, err := w.encoder.Encode(notification)
+	if err != nil {
+		return errors.Wrap(err, "encode notification fail")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(encoded.Body))
+	if err != nil {
+		return errors.Wrap(err, "webhook request fail")
+	}
+	req.Header.Set("Content-Type", encoded.ContentType)
+	// CloudEvents HTTP protocol binding.
+	for k, v := range encoded.Headers {
+		req.Header.Set("ce-"+k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "webhook send fail")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *Webhook) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+// Header is the HTTP header and the gRPC metadata key carrying the request ID.
+const Header = "x-request-id"
+
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a random request ID.
+func New() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		// The system random source failed, the ID is still unique enough to correlate the log lines.
+		return uuid.NewV5(uuid.NamespaceOID, err.Error()).String()
+	}
+	return id.String()
+}
+
+// Valid reports whether a request ID sent by a client can be used as is: at most 128 printable ASCII
+// characters without spaces, so that it cannot break the log lines.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
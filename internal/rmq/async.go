@@ -0,0 +1,192 @@
+package rmq
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Backpressure policies applied when the AsyncPublisher queue is full.
+const (
+	PolicyBlock      = "block"       // Publish waits for free space.
+	PolicyDropOldest = "drop-oldest" // The oldest queued message is discarded.
+	PolicyDropNew    = "drop-new"    // The new message is discarded.
+)
+
+var (
+	ErrQueueFull       = errors.New("publish queue is full")
+	ErrPublisherClosed = errors.New("publisher is closed")
+)
+
+type Publisher interface {
+	Publish(msg amqp.Publishing) error
+}
+
+// AsyncPublisher queues messages in memory and publishes them in batches in the background,
+// so the latency of the broker is not added to the caller's latency.
+type AsyncPublisher struct {
+	next   Publisher
+	logger interfaces.Logger
+
+	capacity      int
+	batchSize     int
+	flushInterval time.Duration
+	policy        string
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   []amqp.Publishing
+	closed  bool
+	dropped uint64
+
+	wakeCh chan struct{}
+	done   chan struct{}
+}
+
+func NewAsyncPublisher(
+	next Publisher,
+	capacity, batchSize int,
+	flushInterval, policy string,
+	logger interfaces.Logger,
+) (*AsyncPublisher, error) {
+	if capacity <= 0 || batchSize <= 0 {
+		return nil, errors.Errorf("queue size and batch size must be positive (%d, %d)", capacity, batchSize)
+	}
+
+	flushIntervalDur, err := time.ParseDuration(flushInterval)
+	if err != nil {
+		return nil, errors.Wrapf(err, "flush interval parsing fail (%s)", flushInterval)
+	}
+	if flushIntervalDur <= 0 {
+		return nil, errors.Errorf("flush interval must be positive (%s)", flushInterval)
+	}
+
+	switch policy {
+	case PolicyBlock, PolicyDropOldest, PolicyDropNew:
+	default:
+		return nil, errors.Errorf("unknown backpressure policy %q", policy)
+	}
+
+	p := &AsyncPublisher{
+		next:          next,
+		logger:        logger,
+		capacity:      capacity,
+		batchSize:     batchSize,
+		flushInterval: flushIntervalDur,
+		policy:        policy,
+		queue:         make([]amqp.Publishing, 0, capacity),
+		wakeCh:        make(chan struct{}, 1),
+		done:          make(chan struct{}),
+	}
+	p.notFull = sync.NewCond(&p.mu)
+
+	go p.run()
+
+	return p, nil
+}
+
+// Publish queues the message. When the queue is full the backpressure policy applies.
+func (p *AsyncPublisher) Publish(msg amqp.Publishing) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for !p.closed && len(p.queue) >= p.capacity {
+		switch p.policy {
+		case PolicyDropOldest:
+			p.queue = p.queue[1:]
+			p.dropped++
+		case PolicyDropNew:
+			p.dropped++
+			return ErrQueueFull
+		default:
+			p.notFull.Wait()
+		}
+	}
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	p.queue = append(p.queue, msg)
+	if len(p.queue) >= p.batchSize {
+		p.wake()
+	}
+	return nil
+}
+
+// Close stops accepting messages and publishes everything still queued.
+func (p *AsyncPublisher) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.notFull.Broadcast()
+	p.mu.Unlock()
+
+	p.wake()
+	<-p.done
+	return nil
+}
+
+// Stats returns the number of queued messages and the number of messages dropped so far.
+func (p *AsyncPublisher) Stats() (int, uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.queue), p.dropped
+}
+
+func (p *AsyncPublisher) wake() {
+	select {
+	case p.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.wakeCh:
+		case <-ticker.C:
+		}
+		if p.flush() {
+			return
+		}
+	}
+}
+
+// flush publishes queued messages batch by batch. A partial batch is sent too, as either the flush
+// interval has passed or the publisher is closing. It reports whether the publisher is closed and drained.
+func (p *AsyncPublisher) flush() bool {
+	for {
+		p.mu.Lock()
+		n := len(p.queue)
+		if n > p.batchSize {
+			n = p.batchSize
+		}
+		batch := make([]amqp.Publishing, n)
+		copy(batch, p.queue[:n])
+		p.queue = p.queue[n:]
+		closed := p.closed
+		rest := len(p.queue)
+		p.notFull.Broadcast()
+		p.mu.Unlock()
+
+		for _, msg := range batch {
+			if err := p.next.Publish(msg); err != nil {
+				p.logger.Error("RMQ async publish failed: %v", err)
+			}
+		}
+
+		if rest == 0 {
+			return closed
+		}
+		if rest < p.batchSize && !closed {
+			return false
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package rmq
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	bodies []string
+	gate   chan struct{} // If set, every Publish waits for a value.
+}
+
+func (r *recordingPublisher) Publish(msg amqp.Publishing) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, string(msg.Body))
+	return nil
+}
+
+func (r *recordingPublisher) published() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func body(i int) amqp.Publishing {
+	return amqp.Publishing{Body: []byte(fmt.Sprint(i))}
+}
+
+func newTestAsync(t *testing.T, next Publisher, capacity, batch int, interval, policy string) *AsyncPublisher {
+	t.Helper()
+	p, err := NewAsyncPublisher(next, capacity, batch, interval, policy, logger.New("error", io.Discard))
+	require.NoError(t, err)
+	return p
+}
+
+func TestAsyncPublisherFlushesOnClose(t *testing.T) {
+	next := &recordingPublisher{}
+	p := newTestAsync(t, next, 100, 10, "1h", PolicyBlock)
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, p.Publish(body(i)))
+	}
+	require.NoError(t, p.Close())
+
+	got := next.published()
+	require.Len(t, got, 25)
+	for i, b := range got {
+		require.Equal(t, fmt.Sprint(i), b)
+	}
+	require.ErrorIs(t, p.Publish(body(26)), ErrPublisherClosed)
+}
+
+func TestAsyncPublisherFlushesOnInterval(t *testing.T) {
+	next := &recordingPublisher{}
+	p := newTestAsync(t, next, 100, 10, "10ms", PolicyBlock)
+	defer p.Close()
+
+	require.NoError(t, p.Publish(body(1)))
+	require.Eventually(t, func() bool {
+		return len(next.published()) == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestAsyncPublisherDropNew(t *testing.T) {
+	next := &recordingPublisher{gate: make(chan struct{})}
+	p := newTestAsync(t, next, 2, 100, "1h", PolicyDropNew)
+
+	require.NoError(t, p.Publish(body(1)))
+	require.NoError(t, p.Publish(body(2)))
+	require.ErrorIs(t, p.Publish(body(3)), ErrQueueFull)
+
+	queued, dropped := p.Stats()
+	require.Equal(t, 2, queued)
+	require.Equal(t, uint64(1), dropped)
+
+	close(next.gate)
+	require.NoError(t, p.Close())
+	require.Equal(t, []string{"1", "2"}, next.published())
+}
+
+func TestAsyncPublisherDropOldest(t *testing.T) {
+	next := &recordingPublisher{gate: make(chan struct{})}
+	p := newTestAsync(t, next, 2, 100, "1h", PolicyDropOldest)
+
+	require.NoError(t, p.Publish(body(1)))
+	require.NoError(t, p.Publish(body(2)))
+	require.NoError(t, p.Publish(body(3)))
+
+	_, dropped := p.Stats()
+	require.Equal(t, uint64(1), dropped)
+
+	close(next.gate)
+	require.NoError(t, p.Close())
+	require.Equal(t, []string{"2", "3"}, next.published())
+}
+
+func TestAsyncPublisherBlock(t *testing.T) {
+	next := &recordingPublisher{}
+	p := newTestAsync(t, next, 1, 100, "1h", PolicyBlock)
+
+	require.NoError(t, p.Publish(body(1)))
+
+	published := make(chan error)
+	go func() {
+		published <- p.Publish(body(2))
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("publish must block while the queue is full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Closing flushes the queue and unblocks the waiting publisher.
+	go p.Close()
+	err := <-published
+	if err != nil {
+		require.ErrorIs(t, err, ErrPublisherClosed)
+	}
+}
+
+func TestAsyncPublisherInvalidConfig(t *testing.T) {
+	next := &recordingPublisher{}
+	log := logger.New("error", io.Discard)
+
+	_, err := NewAsyncPublisher(next, 0, 10, "1s", PolicyBlock, log)
+	require.Error(t, err)
+	_, err = NewAsyncPublisher(next, 10, 10, "soon", PolicyBlock, log)
+	require.Error(t, err)
+	_, err = NewAsyncPublisher(next, 10, 10, "1s", "retry", log)
+	require.Error(t, err)
+}
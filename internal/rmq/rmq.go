@@ -2,20 +2,41 @@ package rmq
 
 import (
 	"context"
+	stderrors "errors"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/metrics"
 	"github.com/pkg/errors"
 	amqp "github.com/rabbitmq/amqp091-go"
-	"github.com/rs/zerolog/log"
 )
 
 var ErrStopReconn = errors.New("stop reconnecting")
 
+const (
+	initConnectTimeout       = 30 * time.Second
+	initConnectRetryInterval = time.Second
+)
+
 type Rmq struct {
+	mu         sync.RWMutex
 	conn       *amqp.Connection
 	channel    *amqp.Channel
 	connClosed chan struct{}
+	stopWatch  context.CancelFunc // Stops the reconnection watcher, called by Close.
+
+	spool     *Spool
+	drainCh   chan struct{}
+	spoolFill atomic.Int32 // Last logged fill of the spool, in tenths of its capacity.
+
+	stateMu   sync.Mutex
+	state     State
+	callbacks []StateCallback
+
+	logger interfaces.Logger
 
 	uri          string
 	exchangeName string
@@ -33,6 +54,7 @@ func New(
 	uri, exchangeName, exchangeType, queueName, bindingKey, reConnMaxElapsedTime, reConnInitialInterval string,
 	reConnMultiplier float64,
 	reConnMaxInterval string,
+	logger interfaces.Logger,
 ) (*Rmq, error) {
 	reConnMaxElapsedTimeDur, err := time.ParseDuration(reConnMaxElapsedTime)
 	if err != nil {
@@ -60,61 +82,237 @@ func New(
 		reConnInitialInterval: reConnInitialIntervalDur,
 		reConnMultiplier:      reConnMultiplier,
 		reConnMaxInterval:     reConnMaxIntervalDur,
+
+		drainCh: make(chan struct{}, 1),
+		state:   StateConnecting,
+		logger:  logger,
 	}, nil
 }
 
+// SetSpool enables buffering of messages on disk while the broker is unavailable. Call it before Init.
+func (r *Rmq) SetSpool(spool *Spool) {
+	r.spool = spool
+}
+
 func (r *Rmq) Init(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.stopWatch = cancel
+	r.mu.Unlock()
+
 	var err error
 	startTime := time.Now()
 
-	for time.Since(startTime) < 30*time.Second {
+	for time.Since(startTime) < initConnectTimeout {
 		if err = r.connect(ctx); err == nil {
 			// Connection success, then exit from loop
 			break
 		}
 		// If the connection fails, wait a little before trying again.
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "rmq connection cancelled")
+		case <-time.After(initConnectRetryInterval):
+		}
 	}
 
 	if err != nil {
-		return errors.Wrap(err, "rmq connection fail")
+		err = errors.Wrap(err, "rmq connection fail")
+		// Keep reconnecting in the background, events are spooled meanwhile if a spool is set.
+		go r.watch(ctx, false)
+		return errors.Wrap(err, "reconnecting in the background")
 	}
 
-	if err := r.prepareQueue(); err != nil {
-		return errors.Wrap(err, "preparing queue fail")
-	}
+	r.setState(StateConnected)
+	r.drainSpool()
+	go r.watch(ctx, true)
 
-	go func() {
-		for {
+	return nil
+}
+
+// watch reconnects whenever the connection is closed and drains the spool after reconnecting.
+// It never gives up: after an exhausted backoff the state becomes StateDisconnected and a new backoff starts.
+func (r *Rmq) watch(ctx context.Context, connected bool) {
+	for {
+		if connected {
 			select {
 			case <-ctx.Done():
 				return
-			case <-r.connClosed:
-				if err := r.reConnect(ctx); err != nil {
-					log.Fatal().Err(err).Msg("reconnecting error")
-				}
+			case <-r.drainCh:
+				r.drainSpool()
+				continue
+			case <-r.closedCh():
+				r.logger.Warning("RMQ connection closed, reconnecting")
+				r.setState(StateConnecting)
 			}
 		}
-	}()
 
-	return nil
+		err := r.reConnect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.logger.Error("RMQ reconnecting error: %v, retrying in %s", err, r.reConnMaxInterval)
+			r.setState(StateDisconnected)
+			connected = false
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(r.reConnMaxInterval):
+			}
+			continue
+		}
+
+		connected = true
+		metrics.RMQReconnects.Inc()
+		r.setState(StateConnected)
+		r.drainSpool()
+	}
+}
+
+func (r *Rmq) drainSpool() {
+	if r.spool == nil || r.spool.Empty() {
+		return
+	}
+
+	drained, err := r.spool.Drain(r.publishSpooled)
+	records, size := r.spool.Depth()
+	if err != nil {
+		r.logger.Error("RMQ spool drain stopped after %d events: %v (depth %d events, %d bytes)",
+			drained, err, records, size)
+		return
+	}
+	r.spoolFill.Store(0)
+	r.logger.Info("RMQ spool drained %d events (depth %d events, %d bytes)", drained, records, size)
+}
+
+// SpoolDepth returns the number of spooled messages and their size in bytes.
+func (r *Rmq) SpoolDepth() (int, int64) {
+	if r.spool == nil {
+		return 0, 0
+	}
+	return r.spool.Depth()
+}
+
+// SpoolStats returns the depth and the capacity of the spool. Without a spool every value is zero.
+func (r *Rmq) SpoolStats() SpoolStats {
+	if r.spool == nil {
+		return SpoolStats{}
+	}
+	return r.spool.Stats()
 }
 
 func (r *Rmq) Close() error {
-	if err := r.channel.Close(); err != nil {
+	r.setState(StateClosed)
+
+	// The watcher must not reconnect once the connection below is closed.
+	r.mu.RLock()
+	if r.stopWatch != nil {
+		r.stopWatch()
+	}
+	r.mu.RUnlock()
+
+	// Every resource is closed even if another one fails to, the connection would leak with its watcher.
+	var errs []error
+	if r.spool != nil {
+		if err := r.spool.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, "spool close fail"))
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.channel != nil {
+		if err := r.channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			errs = append(errs, errors.Wrap(err, "channel close fail"))
+		}
+	}
+	if r.conn != nil {
+		if err := r.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			errs = append(errs, errors.Wrap(err, "connection close fail"))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+// Publish sends the message to the exchange. With a spool configured the message is spooled instead
+// while the broker is unavailable or older spooled messages are still waiting, to keep the order.
+func (r *Rmq) Publish(msg amqp.Publishing) (err error) {
+	ctx, span := r.startPublishSpan(msg)
+	defer func() { endPublishSpan(span, err) }()
+
+	if r.spool == nil {
+		return r.publish(ctx, msg)
+	}
+
+	if r.IsClosed() || !r.spool.Empty() {
+		span.AddEvent("spooled")
+		err := r.spoolMessage(msg)
+		r.requestDrain()
 		return err
 	}
-	return r.conn.Close()
+
+	if err := r.publish(ctx, msg); err != nil {
+		r.logger.Warning("RMQ publish failed, spooling the message: %v", err)
+		span.AddEvent("spooled")
+		return r.spoolMessage(msg)
+	}
+	return nil
+}
+
+// publishSpooled sends a message drained from the spool.
+func (r *Rmq) publishSpooled(msg amqp.Publishing) (err error) {
+	ctx, span := r.startPublishSpan(msg)
+	defer func() { endPublishSpan(span, err) }()
+
+	return r.publish(ctx, msg)
+}
+
+func (r *Rmq) spoolMessage(msg amqp.Publishing) error {
+	if err := r.spool.Append(msg); err != nil {
+		return errors.Wrap(err, "rmq spool fail")
+	}
+	r.logSpoolFill()
+	return nil
+}
+
+// logSpoolFill logs the depth of the spool when the first message is spooled and then at every
+// tenth of its capacity, so the log shows the spool growing without a line per event.
+func (r *Rmq) logSpoolFill() {
+	stats := r.spool.Stats()
+	fill := int32(stats.Bytes * 10 / stats.MaxBytes)
+	last := r.spoolFill.Load()
+	switch {
+	case stats.Records == 1:
+		r.spoolFill.Store(fill)
+		r.logger.Warning("RMQ spooling events (capacity %d bytes)", stats.MaxBytes)
+	case fill > last && r.spoolFill.CompareAndSwap(last, fill):
+		r.logger.Warning("RMQ spool %d%% full: %d events, %d bytes in %d segments",
+			fill*10, stats.Records, stats.Bytes, stats.Segments)
+	}
+}
+
+// requestDrain asks the watcher to drain the spool if it is connected.
+func (r *Rmq) requestDrain() {
+	select {
+	case r.drainCh <- struct{}{}:
+	default:
+	}
 }
 
-func (r *Rmq) Publish(msg amqp.Publishing) error {
-	ctx := context.Background()
-	if r.channel == nil {
+func (r *Rmq) publish(ctx context.Context, msg amqp.Publishing) error {
+	r.mu.RLock()
+	channel := r.channel
+	r.mu.RUnlock()
+	if channel == nil {
 		return nil
 	}
-	if err := r.channel.PublishWithContext(ctx, r.exchangeName, r.queueName, false, false, msg); err != nil {
+	msg = injectTraceContext(ctx, msg)
+	if err := channel.PublishWithContext(ctx, r.exchangeName, r.queueName, false, false, msg); err != nil {
+		metrics.RMQPublishes.WithLabelValues("failure").Inc()
 		return errors.Wrap(err, "rmq publish fail")
 	}
+	metrics.RMQPublishes.WithLabelValues("success").Inc()
 
 	return nil
 }
@@ -145,7 +343,15 @@ func (r *Rmq) Qos(prefetchCount int) error {
 }
 
 func (r *Rmq) IsClosed() bool {
-	return r.conn.IsClosed()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.conn == nil || r.conn.IsClosed() || r.channel == nil || r.channel.IsClosed()
+}
+
+func (r *Rmq) closedCh() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.connClosed
 }
 
 // Reconnecting algo.
@@ -167,14 +373,10 @@ func (r *Rmq) reConnect(ctx context.Context) error {
 		case <-ctx.Done():
 			return nil
 		case <-time.After(d):
-			log.Warn().Str("after", d.String()).Msg("reconnection")
+			r.logger.Warning("RMQ reconnection after %s", d)
 
 			if err := r.connect(ctx); err != nil {
-				log.Error().Err(err).Msg("couldn't connect in reconnect call")
-				continue
-			}
-			if err := r.prepareQueue(); err != nil {
-				log.Error().Err(err).Msg("couldn't preparing queue in reconnect call")
+				r.logger.Error("RMQ couldn't connect in reconnect call: %v", err)
 				continue
 			}
 
@@ -183,32 +385,49 @@ func (r *Rmq) reConnect(ctx context.Context) error {
 	}
 }
 
-// Connect to RabbitMQ.
+// Connect to RabbitMQ and declare the exchange and the queue. The new connection replaces the previous one
+// only once it is ready, it is closed on failure so that retries do not leak connections.
 func (r *Rmq) connect(ctx context.Context) error {
-	var err error
-
-	r.conn, err = amqp.Dial(r.uri)
+	conn, err := amqp.Dial(r.uri)
 	if err != nil {
 		return errors.Wrap(err, "dial fail")
 	}
 
-	r.channel, err = r.conn.Channel()
+	channel, err := conn.Channel()
 	if err != nil {
+		_ = conn.Close()
 		return errors.Wrap(err, "channel fail")
 	}
 
-	r.connClosed = make(chan struct{})
+	if err := r.prepareQueue(channel); err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	connClosed := make(chan struct{})
+	r.mu.Lock()
+	previous := r.conn
+	r.conn, r.channel, r.connClosed = conn, channel, connClosed
+	r.mu.Unlock()
+	if previous != nil {
+		_ = previous.Close()
+	}
 
 	// Event for closing channel
 	go func() {
 		select {
 		case <-ctx.Done():
-		case <-r.conn.NotifyClose(make(chan *amqp.Error)):
-			close(r.connClosed)
+		case <-conn.NotifyClose(make(chan *amqp.Error, 1)):
+			close(connClosed)
 		}
 	}()
 
-	if err := r.channel.ExchangeDeclare(
+	return nil
+}
+
+// Declare the exchange and the queue.
+func (r *Rmq) prepareQueue(channel *amqp.Channel) error {
+	if err := channel.ExchangeDeclare(
 		r.exchangeName,
 		r.exchangeType,
 		true,
@@ -220,12 +439,7 @@ func (r *Rmq) connect(ctx context.Context) error {
 		return errors.Wrap(err, "exchange declare fail")
 	}
 
-	return nil
-}
-
-// Declare queue.
-func (r *Rmq) prepareQueue() error {
-	_, err := r.channel.QueueDeclare(
+	_, err := channel.QueueDeclare(
 		r.queueName,
 		true,
 		false,
@@ -238,7 +452,7 @@ func (r *Rmq) prepareQueue() error {
 	}
 
 	// Create a binding (exchange rule).
-	if err = r.channel.QueueBind(
+	if err = channel.QueueBind(
 		r.queueName,
 		r.bindingKey,
 		r.exchangeName,
@@ -0,0 +1,366 @@
+package rmq
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var ErrSpoolFull = errors.New("spool is full")
+
+const (
+	segmentExt       = ".seg"
+	recordHeaderSize = 4
+)
+
+// Spool is a bounded on-disk FIFO of messages that could not be published.
+// Messages are appended to segment files; a segment is deleted once all of its messages are drained.
+// Delivery is at-least-once: after a restart a partially drained segment is drained from its beginning.
+type Spool struct {
+	mu          sync.Mutex
+	dir         string
+	segmentSize int64
+	maxSize     int64
+
+	segments []*segment // Oldest first, messages are appended to the last one.
+	writer   *os.File
+	reader   *os.File
+	readOff  int64 // Read position in segments[0].
+	records  int
+	size     int64 // Bytes not yet drained.
+}
+
+type segment struct {
+	seq     uint64
+	path    string
+	size    int64
+	records int // Messages not yet drained.
+}
+
+type spooledMessage struct {
+	ContentType string     `json:"contentType"`
+	Headers     amqp.Table `json:"headers,omitempty"`
+	Body        []byte     `json:"body"`
+}
+
+// NewSpool opens the spool in dir, recovering the messages left by a previous run.
+func NewSpool(dir string, segmentSize, maxSize int64) (*Spool, error) {
+	if segmentSize <= 0 || maxSize <= 0 {
+		return nil, errors.Errorf("spool sizes must be positive (segment %d, max %d)", segmentSize, maxSize)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, errors.Wrapf(err, "create spool dir fail (%s)", dir)
+	}
+
+	s := &Spool{
+		dir:         dir,
+		segmentSize: segmentSize,
+		maxSize:     maxSize,
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append stores the message at the tail of the spool.
+func (s *Spool) Append(msg amqp.Publishing) error {
+	payload, err := json.Marshal(spooledMessage{ContentType: msg.ContentType, Headers: msg.Headers, Body: msg.Body})
+	if err != nil {
+		return errors.Wrap(err, "spool encode fail")
+	}
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record, uint32(len(payload)))
+	copy(record[recordHeaderSize:], payload)
+	recLen := int64(len(record))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+recLen > s.maxSize {
+		return ErrSpoolFull
+	}
+
+	if s.writer == nil || s.segments[len(s.segments)-1].size+recLen > s.segmentSize {
+		if err := s.newSegment(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.writer.Write(record); err != nil {
+		return errors.Wrap(err, "spool write fail")
+	}
+	s.segments[len(s.segments)-1].size += recLen
+	s.segments[len(s.segments)-1].records++
+	s.records++
+	s.size += recLen
+
+	return nil
+}
+
+// Drain publishes the spooled messages in order until the spool is empty or publish fails.
+// A message is removed from the spool only after publish succeeded.
+func (s *Spool) Drain(publish func(amqp.Publishing) error) (int, error) {
+	drained := 0
+	for {
+		msg, recLen, ok, err := s.peek()
+		if err != nil {
+			return drained, err
+		}
+		if !ok {
+			return drained, nil
+		}
+
+		if err := publish(msg); err != nil {
+			return drained, err
+		}
+
+		if err := s.commit(recLen); err != nil {
+			return drained, err
+		}
+		drained++
+	}
+}
+
+// SpoolStats describes the content of the spool.
+type SpoolStats struct {
+	Records  int   // Messages not yet drained.
+	Bytes    int64 // Size of the messages not yet drained.
+	MaxBytes int64 // Capacity, Append fails with ErrSpoolFull beyond it.
+	Segments int   // Segment files on disk.
+}
+
+// Stats returns the depth and the capacity of the spool.
+func (s *Spool) Stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SpoolStats{Records: s.records, Bytes: s.size, MaxBytes: s.maxSize, Segments: len(s.segments)}
+}
+
+// Depth returns the number of spooled messages and their size in bytes.
+func (s *Spool) Depth() (int, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records, s.size
+}
+
+func (s *Spool) Empty() bool {
+	records, _ := s.Depth()
+	return records == 0
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if s.writer != nil {
+		err := s.writer.Close()
+		s.writer = nil
+		return err
+	}
+	return nil
+}
+
+func (s *Spool) peek() (amqp.Publishing, int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.records > 0 {
+		if s.reader == nil {
+			f, err := os.Open(s.segments[0].path)
+			if err != nil {
+				return amqp.Publishing{}, 0, false, errors.Wrap(err, "spool open segment fail")
+			}
+			s.reader = f
+		}
+
+		msg, recLen, err := readRecord(s.reader, s.readOff, s.segments[0].size)
+		if err == nil {
+			return msg, recLen, true, nil
+		}
+
+		// The rest of a corrupted segment cannot be framed, so it is dropped.
+		if err := s.dropHead(); err != nil {
+			return amqp.Publishing{}, 0, false, err
+		}
+		return amqp.Publishing{}, 0, false, errors.Wrap(err, "spool segment corrupted, dropped")
+	}
+	return amqp.Publishing{}, 0, false, nil
+}
+
+func (s *Spool) commit(recLen int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOff += recLen
+	s.segments[0].records--
+	s.records--
+	s.size -= recLen
+
+	if s.readOff < s.segments[0].size {
+		return nil
+	}
+	return s.removeHead()
+}
+
+// dropHead discards the undrained messages of the oldest segment.
+func (s *Spool) dropHead() error {
+	head := s.segments[0]
+	s.records -= head.records
+	s.size -= head.size - s.readOff
+	return s.removeHead()
+}
+
+func (s *Spool) removeHead() error {
+	head := s.segments[0]
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if len(s.segments) == 1 && s.writer != nil {
+		_ = s.writer.Close()
+		s.writer = nil
+	}
+	s.segments = s.segments[1:]
+	s.readOff = 0
+
+	if err := os.Remove(head.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "spool remove segment fail")
+	}
+	return nil
+}
+
+func (s *Spool) newSegment() error {
+	var seq uint64 = 1
+	if len(s.segments) > 0 {
+		seq = s.segments[len(s.segments)-1].seq + 1
+	}
+	if s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			return errors.Wrap(err, "spool close segment fail")
+		}
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "spool create segment fail")
+	}
+	s.writer = f
+	s.segments = append(s.segments, &segment{seq: seq, path: path})
+	return nil
+}
+
+// recover loads the segments found in the spool dir. A record truncated by a crash is cut off.
+func (s *Spool) recover() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return errors.Wrap(err, "read spool dir fail")
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), segmentExt) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var seq uint64
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, segmentExt), "%d", &seq); err != nil {
+			continue
+		}
+		path := filepath.Join(s.dir, name)
+
+		records, validSize, err := scanSegment(path)
+		if err != nil {
+			return err
+		}
+		if records == 0 {
+			_ = os.Remove(path)
+			continue
+		}
+		if err := os.Truncate(path, validSize); err != nil {
+			return errors.Wrap(err, "spool truncate segment fail")
+		}
+
+		s.segments = append(s.segments, &segment{seq: seq, path: path, size: validSize, records: records})
+		s.records += records
+		s.size += validSize
+	}
+
+	if len(s.segments) > 0 {
+		last := s.segments[len(s.segments)-1]
+		f, err := os.OpenFile(last.path, os.O_APPEND|os.O_WRONLY, 0o640) //nolint:gosec
+		if err != nil {
+			return errors.Wrap(err, "spool open segment fail")
+		}
+		s.writer = f
+	}
+	return nil
+}
+
+// scanSegment returns the number of complete records and the size they occupy.
+func scanSegment(path string) (int, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "spool open segment fail")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "spool stat segment fail")
+	}
+
+	var (
+		records int
+		off     int64
+	)
+	for {
+		_, recLen, err := readRecord(f, off, info.Size())
+		if err != nil {
+			return records, off, nil //nolint:nilerr
+		}
+		records++
+		off += recLen
+	}
+}
+
+// readRecord reads the record at off of a segment ending at end. The length prefix is checked against
+// the end of the segment before anything is allocated, a corrupted prefix must not exhaust the memory.
+func readRecord(r io.ReaderAt, off, end int64) (amqp.Publishing, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := r.ReadAt(header, off); err != nil {
+		return amqp.Publishing{}, 0, err
+	}
+	payloadLen := int64(binary.BigEndian.Uint32(header))
+	if payloadLen > end-off-recordHeaderSize {
+		return amqp.Publishing{}, 0, errors.Errorf("record length %d exceeds the segment", payloadLen)
+	}
+	payload := make([]byte, payloadLen)
+	if _, err := r.ReadAt(payload, off+recordHeaderSize); err != nil {
+		return amqp.Publishing{}, 0, err
+	}
+
+	var m spooledMessage
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return amqp.Publishing{}, 0, err
+	}
+	return amqp.Publishing{ContentType: m.ContentType, Headers: m.Headers, Body: m.Body},
+		int64(recordHeaderSize + len(payload)), nil
+}
@@ -0,0 +1,171 @@
+package rmq
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(i int) amqp.Publishing {
+	return amqp.Publishing{
+		ContentType: "application/json",
+		Headers:     amqp.Table{"cloudEvents:id": fmt.Sprintf("id-%d", i)},
+		Body:        []byte(fmt.Sprintf(`{"n":%d}`, i)),
+	}
+}
+
+func collect(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var bodies []string
+	_, err := s.Drain(func(msg amqp.Publishing) error {
+		bodies = append(bodies, string(msg.Body))
+		return nil
+	})
+	require.NoError(t, err)
+	return bodies
+}
+
+func TestSpoolDrainInOrder(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 64, 1<<20)
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, s.Append(testMessage(i)))
+	}
+	records, size := s.Depth()
+	require.Equal(t, 10, records)
+	require.Positive(t, size)
+
+	var got []amqp.Publishing
+	drained, err := s.Drain(func(msg amqp.Publishing) error {
+		got = append(got, msg)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 10, drained)
+	for i, msg := range got {
+		require.Equal(t, testMessage(i).Body, msg.Body)
+		require.Equal(t, "application/json", msg.ContentType)
+		require.Equal(t, fmt.Sprintf("id-%d", i), msg.Headers["cloudEvents:id"])
+	}
+
+	records, size = s.Depth()
+	require.Zero(t, records)
+	require.Zero(t, size)
+
+	// Drained segments are removed.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestSpoolDrainStopsOnPublishError(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), 64, 1<<20)
+	require.NoError(t, err)
+	defer s.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, s.Append(testMessage(i)))
+	}
+
+	calls := 0
+	drained, err := s.Drain(func(amqp.Publishing) error {
+		calls++
+		if calls == 3 {
+			return errors.New("broker is down")
+		}
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, 2, drained)
+
+	// The failed message is retried first.
+	require.Equal(t, []string{`{"n":2}`, `{"n":3}`, `{"n":4}`}, collect(t, s))
+}
+
+func TestSpoolFull(t *testing.T) {
+	s, err := NewSpool(t.TempDir(), 1<<10, 150)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append(testMessage(0)))
+	require.ErrorIs(t, s.Append(testMessage(1)), ErrSpoolFull)
+
+	records, _ := s.Depth()
+	require.Equal(t, 1, records)
+}
+
+func TestSpoolRecover(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 64, 1<<20)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, s.Append(testMessage(i)))
+	}
+	require.NoError(t, s.Close())
+
+	// Simulate a crash in the middle of writing a record.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	last := filepath.Join(dir, entries[len(entries)-1].Name())
+	f, err := os.OpenFile(last, os.O_APPEND|os.O_WRONLY, 0o640)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 0, 100, '{'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = NewSpool(dir, 64, 1<<20)
+	require.NoError(t, err)
+	defer s.Close()
+
+	records, _ := s.Depth()
+	require.Equal(t, 4, records)
+
+	require.NoError(t, s.Append(testMessage(4)))
+	require.Equal(t, []string{`{"n":0}`, `{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`}, collect(t, s))
+}
+
+func TestSpoolRecoverCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSpool(dir, 1024, 1<<20)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.Append(testMessage(i)))
+	}
+	require.NoError(t, s.Close())
+
+	// A corrupted length prefix claims a 4 GiB record.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	path := filepath.Join(dir, entries[0].Name())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	validSize := info.Size()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o640)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xff, 0xff, 0xff, 0xff, '{', '"'})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	s, err = NewSpool(dir, 1024, 1<<20)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// The corrupted tail is cut off.
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, validSize, info.Size())
+	require.Equal(t, SpoolStats{Records: 2, Bytes: validSize, MaxBytes: 1 << 20, Segments: 1}, s.Stats())
+
+	require.NoError(t, s.Append(testMessage(2)))
+	require.Equal(t, []string{`{"n":0}`, `{"n":1}`, `{"n":2}`}, collect(t, s))
+	require.Equal(t, SpoolStats{MaxBytes: 1 << 20}, s.Stats())
+}
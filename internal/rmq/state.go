@@ -0,0 +1,65 @@
+package rmq
+
+// State is the state of the connection to the broker.
+type State int32
+
+const (
+	// StateConnecting means the connection is being established or restored.
+	StateConnecting State = iota
+	// StateConnected means messages are published to the broker.
+	StateConnected
+	// StateDisconnected means a reconnection backoff was exhausted. Reconnection still goes on
+	// with the max interval, and publishers may degrade to a fallback meanwhile.
+	StateDisconnected
+	// StateClosed means Close was called.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateCallback is called on every state change. It must not block.
+type StateCallback func(from, to State)
+
+// State returns the current state of the connection.
+func (r *Rmq) State() State {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	return r.state
+}
+
+// OnStateChange registers a callback called on every state change.
+func (r *Rmq) OnStateChange(cb StateCallback) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	r.callbacks = append(r.callbacks, cb)
+}
+
+func (r *Rmq) setState(to State) {
+	r.stateMu.Lock()
+	from := r.state
+	if from == to || from == StateClosed {
+		r.stateMu.Unlock()
+		return
+	}
+	r.state = to
+	callbacks := append([]StateCallback(nil), r.callbacks...)
+	r.stateMu.Unlock()
+
+	r.logger.Info("RMQ connection state changed: %s -> %s", from, to)
+	for _, cb := range callbacks {
+		cb(from, to)
+	}
+}
@@ -0,0 +1,75 @@
+package rmq
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cronnoss/banners-rotation/internal/rmq")
+
+// HeadersCarrier adapts the AMQP message headers to the OpenTelemetry propagators.
+// Publishers inject the trace context into the headers and consumers extract it to continue the trace:
+//
+//	ctx := otel.GetTextMapPropagator().Extract(ctx, rmq.HeadersCarrier(delivery.Headers))
+type HeadersCarrier amqp.Table
+
+func (c HeadersCarrier) Get(key string) string {
+	value, ok := c[key].(string)
+	if !ok {
+		return ""
+	}
+	return value
+}
+
+func (c HeadersCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c HeadersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// startPublishSpan starts a producer span. Its parent is the trace context in the message headers,
+// as the message may have waited in the async queue or in the spool since the event.
+func (r *Rmq) startPublishSpan(msg amqp.Publishing) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), HeadersCarrier(msg.Headers))
+	return tracer.Start(ctx, fmt.Sprintf("%s publish", r.exchangeName),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("rabbitmq"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(r.exchangeName),
+			semconv.MessagingRabbitmqDestinationRoutingKey(r.queueName),
+		),
+	)
+}
+
+func endPublishSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+// injectTraceContext returns the message with the trace context of ctx in a copy of its headers,
+// so that the consumers continue the trace from the publish span.
+func injectTraceContext(ctx context.Context, msg amqp.Publishing) amqp.Publishing {
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, HeadersCarrier(headers))
+	msg.Headers = headers
+	return msg
+}
@@ -0,0 +1,32 @@
+package rmq
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	msg := amqp.Publishing{Headers: amqp.Table{"traceparent": traceparent, "cloudEvents:id": "1"}}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), HeadersCarrier(msg.Headers))
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+
+	spanID, err := trace.SpanIDFromHex("b7ad6b7169203331")
+	require.NoError(t, err)
+	child := trace.ContextWithSpanContext(ctx, trace.SpanContextFromContext(ctx).WithSpanID(spanID))
+
+	injected := injectTraceContext(child, msg)
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-b7ad6b7169203331-01", injected.Headers["traceparent"])
+	require.Equal(t, "1", injected.Headers["cloudEvents:id"])
+
+	// The original headers, which may be spooled, are left untouched.
+	require.Equal(t, traceparent, msg.Headers["traceparent"])
+}
@@ -0,0 +1,210 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	maxBodySize       = 1 << 10
+)
+
+// BuildInfo describes the running binary. The fields are set with -ldflags at build time.
+type BuildInfo struct {
+	Release   string `json:"release"`
+	BuildDate string `json:"buildDate"`
+	GitHash   string `json:"gitHash"`
+}
+
+// Server is the operational HTTP listener. It is kept apart from the gateway so that it can be
+// exposed to the monitoring network only, it listens on localhost by default. It serves:
+//
+//	GET      /metrics         Prometheus metrics
+//	GET      /debug/pprof/    profiles of net/http/pprof, protected
+//	GET      /buildinfo       build info of the binary
+//	GET      /config          effective config with the secrets redacted, protected
+//	GET, PUT /loglevel        log level, changed with a {"level": "debug"} body, PUT is protected
+//
+// The protected endpoints require the admin credentials of SetAuthorizer when it is set.
+// Without it they are open to anyone reaching the listener, which must then stay internal.
+type Server struct {
+	logger    *logger.Logger
+	mux       *http.ServeMux
+	server    *http.Server
+	buildInfo BuildInfo
+	config    interface{}
+	authorize func(r *http.Request) error
+}
+
+func NewServer(logg *logger.Logger, host string, port int) *Server {
+	s := &Server{
+		logger: logg,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.Handle("/metrics", metrics.Handler())
+	s.mux.HandleFunc("/debug/pprof/", s.protected(pprof.Index))
+	s.mux.HandleFunc("/debug/pprof/cmdline", s.protected(pprof.Cmdline))
+	s.mux.HandleFunc("/debug/pprof/profile", s.protected(pprof.Profile))
+	s.mux.HandleFunc("/debug/pprof/symbol", s.protected(pprof.Symbol))
+	s.mux.HandleFunc("/debug/pprof/trace", s.protected(pprof.Trace))
+	s.mux.HandleFunc("/buildinfo", s.handleBuildInfo)
+	s.mux.HandleFunc("/config", s.protected(s.handleConfig))
+	s.mux.HandleFunc("/loglevel", s.handleLogLevel)
+
+	s.server = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", host, port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	return s
+}
+
+func (s *Server) SetBuildInfo(info BuildInfo) {
+	s.buildInfo = info
+}
+
+// SetConfig sets the config served by /config. The secrets must already be redacted.
+func (s *Server) SetConfig(config interface{}) {
+	s.config = config
+}
+
+// SetAuthorizer protects the endpoints changing the service or exposing its internals. The authorize
+// function returns a gRPC status error, Unauthenticated or PermissionDenied, for rejected requests.
+func (s *Server) SetAuthorizer(authorize func(r *http.Request) error) {
+	s.authorize = authorize
+}
+
+// Handle registers an additional endpoint. It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) Start() error {
+	s.logger.Info("Starting admin server on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// Handler returns the HTTP handler of the admin server.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+func (s *Server) handleBuildInfo(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, struct {
+		BuildInfo
+		GoVersion string `json:"goVersion"`
+	}{s.buildInfo, runtime.Version()})
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.config)
+}
+
+type logLevel struct {
+	Level string `json:"level"`
+}
+
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if !s.authorized(w, r) {
+			return
+		}
+		var req logLevel
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&req); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, errorBody(fmt.Errorf("invalid body: %w", err)))
+			return
+		}
+		previous := s.logger.Level()
+		if err := s.logger.SetLevel(req.Level); err != nil {
+			s.writeJSON(w, http.StatusBadRequest, errorBody(err))
+			return
+		}
+		s.logger.Warning("Log level changed from %s to %s", previous, s.logger.Level())
+	}
+
+	s.writeJSON(w, http.StatusOK, logLevel{Level: string(s.logger.Level())})
+}
+
+func (s *Server) protected(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.authorized(w, r) {
+			handler(w, r)
+		}
+	}
+}
+
+// authorized replies 401 or 403 if the request is rejected by the authorizer.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.authorize == nil {
+		return true
+	}
+	err := s.authorize(r)
+	if err == nil {
+		return true
+	}
+
+	code := http.StatusForbidden
+	if status.Code(err) == codes.Unauthenticated {
+		code = http.StatusUnauthorized
+	}
+	s.writeJSON(w, code, errorBody(errors.New(status.Convert(err).Message())))
+	return false
+}
+
+// allowMethods replies 405 if the request method is not one of methods.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	for _, method := range methods {
+		w.Header().Add("Allow", method)
+	}
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	return false
+}
+
+func errorBody(err error) interface{} {
+	return struct {
+		Error string `json:"error"`
+	}{err.Error()}
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("Failed to write admin response: %v", err)
+	}
+}
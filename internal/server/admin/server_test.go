@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmin(t *testing.T) {
+	logg := logger.New("info", io.Discard)
+	s := NewServer(logg, "localhost", 0)
+	s.SetBuildInfo(BuildInfo{Release: "v1.2.3", BuildDate: "2026-10-19T11:00:00", GitHash: "abc1234"})
+	s.SetConfig(map[string]interface{}{"database": map[string]interface{}{"password": "[REDACTED]"}})
+
+	do := func(method, path, body string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp map[string]interface{}
+		if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec.Code, resp
+	}
+
+	code, resp := do(http.MethodGet, "/buildinfo", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "v1.2.3", resp["release"])
+	require.Equal(t, "abc1234", resp["gitHash"])
+	require.NotEmpty(t, resp["goVersion"])
+
+	code, resp = do(http.MethodGet, "/config", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]interface{}{"password": "[REDACTED]"}, resp["database"])
+
+	code, resp = do(http.MethodGet, "/loglevel", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, logger.Info, resp["level"])
+
+	code, resp = do(http.MethodPut, "/loglevel", `{"level": "debug"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, logger.Debug, resp["level"])
+	require.Equal(t, logger.LogLevel(logger.Debug), logg.Level())
+
+	code, _ = do(http.MethodPut, "/loglevel", `{"level": "verbose"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, logger.LogLevel(logger.Debug), logg.Level())
+
+	code, _ = do(http.MethodPost, "/loglevel", `{"level": "info"}`)
+	require.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, _ = do(http.MethodGet, "/debug/pprof/", "")
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = do(http.MethodGet, "/metrics", "")
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestAdminAuthorizer(t *testing.T) {
+	logg := logger.New("info", io.Discard)
+	s := NewServer(logg, "localhost", 0)
+	s.SetAuthorizer(func(r *http.Request) error {
+		switch r.Header.Get("x-api-key") {
+		case "admin-key":
+			return nil
+		case "client-key":
+			return status.Error(codes.PermissionDenied, "role \"client\" is not allowed")
+		default:
+			return status.Error(codes.Unauthenticated, "missing credentials")
+		}
+	})
+
+	do := func(method, path, key, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("x-api-key", key)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/loglevel", "", `{"level": "debug"}`))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPut, "/loglevel", "client-key", `{"level": "debug"}`))
+	require.Equal(t, logger.LogLevel(logger.Info), logg.Level())
+	require.Equal(t, http.StatusOK, do(http.MethodPut, "/loglevel", "admin-key", `{"level": "debug"}`))
+	require.Equal(t, logger.LogLevel(logger.Debug), logg.Level())
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/debug/pprof/", "", ""))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/debug/pprof/", "admin-key", ""))
+
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/config", "", ""))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/config", "admin-key", ""))
+
+	// Reading the log level and the metrics needs no credentials.
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/loglevel", "", ""))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/metrics", "", ""))
+}
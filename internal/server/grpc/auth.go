@@ -0,0 +1,280 @@
+package internalgrpc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Roles of API keys and JWTs. The admin role is allowed to call everything.
+const (
+	RoleAdmin  = "admin"
+	RoleClient = "client"
+)
+
+const (
+	apiKeyHeader        = "x-api-key"
+	authorizationHeader = "authorization"
+	bearerPrefix        = "bearer "
+)
+
+// methodRoles are the roles required by the BannerService methods.
+// Methods not listed here require the admin role, other services (health, reflection) are public.
+var methodRoles = map[string]string{
+	"AddBanner":         RoleAdmin,
+	"RemoveBanner":      RoleAdmin,
+	"PickBanner":        RoleClient,
+	"ClickBanner":       RoleClient,
+	"ConfirmImpression": RoleClient,
+	"WatchEvents":       RoleAdmin,
+}
+
+var (
+	errMissingCredentials = errors.New("missing credentials")
+	errJWTDisabled        = errors.New("bearer tokens are not accepted")
+)
+
+// KeyStore looks up API keys by the hex SHA-256 of the key.
+type KeyStore interface {
+	APIKeyRole(ctx context.Context, keyHash string) (string, error)
+}
+
+// Identity is the authenticated caller, stored in the context of authorized requests.
+type Identity struct {
+	Subject string
+	Role    string
+}
+
+type identityKey struct{}
+
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+type jwtClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type cachedKey struct {
+	role    string
+	expires time.Time
+}
+
+// AuthInterceptor authenticates the callers of BannerService by an API key in the x-api-key metadata
+// or by a JWT in the "authorization: Bearer" metadata, and checks the role required by the method.
+type AuthInterceptor struct {
+	keys       KeyStore
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	parser     *jwt.Parser
+
+	cacheTTL time.Duration
+	mu       sync.Mutex
+	cache    map[string]cachedKey
+}
+
+// NewAuthInterceptor creates the interceptor. HS256 JWTs are accepted if hmacSecret is set,
+// RS256 JWTs if rsaPublicKeyPEM is set, and bearer tokens are rejected when neither is.
+// Issuer and audience are checked when not empty.
+func NewAuthInterceptor(
+	keys KeyStore,
+	hmacSecret string,
+	rsaPublicKeyPEM []byte,
+	issuer, audience string,
+	cacheTTL time.Duration,
+) (*AuthInterceptor, error) {
+	a := &AuthInterceptor{
+		keys:     keys,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]cachedKey),
+	}
+
+	var methods []string
+	if hmacSecret != "" {
+		a.hmacSecret = []byte(hmacSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(rsaPublicKeyPEM) > 0 {
+		key, err := jwt.ParseRSAPublicKeyFromPEM(rsaPublicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA public key: %w", err)
+		}
+		a.rsaKey = key
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *AuthInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *AuthInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *AuthInterceptor) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	required, ok := requiredRole(fullMethod)
+	if !ok {
+		return ctx, nil
+	}
+
+	identity, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if identity.Role != required && identity.Role != RoleAdmin {
+		return nil, status.Errorf(codes.PermissionDenied, "role %q is not allowed to call %s",
+			identity.Role, methodFromFullMethod(fullMethod))
+	}
+
+	return context.WithValue(ctx, identityKey{}, identity), nil
+}
+
+// AuthorizeAdmin authenticates an HTTP request by the same x-api-key or "Authorization: Bearer" headers
+// as the gRPC calls and requires the admin role. It protects the sensitive endpoints of the admin server.
+func (a *AuthInterceptor) AuthorizeAdmin(r *http.Request) error {
+	md := metadata.MD{}
+	for _, header := range []string{apiKeyHeader, authorizationHeader} {
+		if value := r.Header.Get(header); value != "" {
+			md.Set(header, value)
+		}
+	}
+
+	identity, err := a.authenticate(metadata.NewIncomingContext(r.Context(), md))
+	if err != nil {
+		return err
+	}
+	if identity.Role != RoleAdmin {
+		return status.Errorf(codes.PermissionDenied, "role %q is not allowed to call %s", identity.Role, r.URL.Path)
+	}
+	return nil
+}
+
+func requiredRole(fullMethod string) (string, bool) {
+	method, ok := bannerServiceMethod(fullMethod)
+	if !ok {
+		return "", false
+	}
+	if role, ok := methodRoles[method]; ok {
+		return role, true
+	}
+	return RoleAdmin, true
+}
+
+func (a *AuthInterceptor) authenticate(ctx context.Context) (Identity, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if keys := md.Get(apiKeyHeader); len(keys) > 0 && keys[0] != "" {
+		return a.apiKeyIdentity(ctx, keys[0])
+	}
+
+	if values := md.Get(authorizationHeader); len(values) > 0 {
+		if len(values[0]) > len(bearerPrefix) && strings.EqualFold(values[0][:len(bearerPrefix)], bearerPrefix) {
+			return a.jwtIdentity(values[0][len(bearerPrefix):])
+		}
+	}
+
+	return Identity{}, status.Error(codes.Unauthenticated, errMissingCredentials.Error())
+}
+
+func (a *AuthInterceptor) apiKeyIdentity(ctx context.Context, key string) (Identity, error) {
+	sum := sha256.Sum256([]byte(key))
+	keyHash := hex.EncodeToString(sum[:])
+	subject := "apikey:" + keyHash[:12]
+
+	a.mu.Lock()
+	cached, ok := a.cache[keyHash]
+	a.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return Identity{Subject: subject, Role: cached.role}, nil
+	}
+
+	role, err := a.keys.APIKeyRole(ctx, keyHash)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Identity{}, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	if err != nil {
+		return Identity{}, status.Errorf(codes.Internal, "failed to check api key: %v", err)
+	}
+
+	// Only valid keys are cached, so random keys cannot grow the cache.
+	if a.cacheTTL > 0 {
+		a.mu.Lock()
+		a.cache[keyHash] = cachedKey{role: role, expires: time.Now().Add(a.cacheTTL)}
+		a.mu.Unlock()
+	}
+
+	return Identity{Subject: subject, Role: role}, nil
+}
+
+func (a *AuthInterceptor) jwtIdentity(raw string) (Identity, error) {
+	// With no key jwt.WithValidMethods(nil) would allow every algorithm, so never reach the parser.
+	if len(a.hmacSecret) == 0 && a.rsaKey == nil {
+		return Identity{}, status.Error(codes.Unauthenticated, errJWTDisabled.Error())
+	}
+
+	claims := &jwtClaims{}
+	if _, err := a.parser.ParseWithClaims(raw, claims, a.jwtKey); err != nil {
+		return Identity{}, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	return Identity{Subject: "jwt:" + claims.Subject, Role: claims.Role}, nil
+}
+
+// jwtKey returns the key of the token's algorithm family, only if that family is configured.
+func (a *AuthInterceptor) jwtKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(a.hmacSecret) > 0 {
+			return a.hmacSecret, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if a.rsaKey != nil {
+			return a.rsaKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
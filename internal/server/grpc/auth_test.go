@@ -0,0 +1,200 @@
+package internalgrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type keyStore struct {
+	roles   map[string]string
+	lookups int
+}
+
+func (k *keyStore) APIKeyRole(_ context.Context, keyHash string) (string, error) {
+	k.lookups++
+	role, ok := k.roles[keyHash]
+	if !ok {
+		return "", storage.ErrNotFound
+	}
+	return role, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func callAs(t *testing.T, a *AuthInterceptor, method string, md metadata.MD) (Identity, error) {
+	t.Helper()
+	var identity Identity
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	_, err := a.UnaryServerInterceptor(ctx, nil,
+		&grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			identity, _ = IdentityFromContext(ctx)
+			return nil, nil
+		})
+	return identity, err
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	keys := &keyStore{roles: map[string]string{
+		hashKey("admin-key"):  RoleAdmin,
+		hashKey("client-key"): RoleClient,
+	}}
+	a, err := NewAuthInterceptor(keys, "", nil, "", "", time.Minute)
+	require.NoError(t, err)
+
+	_, err = callAs(t, a, "/banner.BannerService/AddBanner", metadata.Pairs("x-api-key", "admin-key"))
+	require.NoError(t, err)
+
+	identity, err := callAs(t, a, "/banner.BannerService/PickBanner", metadata.Pairs("x-api-key", "client-key"))
+	require.NoError(t, err)
+	require.Equal(t, RoleClient, identity.Role)
+
+	_, err = callAs(t, a, "/banner.BannerService/RemoveBanner", metadata.Pairs("x-api-key", "client-key"))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = callAs(t, a, "/banner.BannerService/PickBanner", metadata.Pairs("x-api-key", "unknown"))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = callAs(t, a, "/banner.BannerService/PickBanner", metadata.MD{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Health checks and reflection need no credentials.
+	_, err = callAs(t, a, "/grpc.health.v1.Health/Check", metadata.MD{})
+	require.NoError(t, err)
+
+	// Valid keys are cached: admin-key, client-key and unknown were looked up once each.
+	require.Equal(t, 3, keys.lookups)
+}
+
+func TestAuthJWT(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	a, err := NewAuthInterceptor(&keyStore{}, "secret", publicPEM, "issuer", "", time.Minute)
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, key interface{}, role string, expires time.Time) metadata.MD {
+		token, err := jwt.NewWithClaims(method, jwtClaims{
+			Role: role,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "dashboard",
+				Issuer:    "issuer",
+				ExpiresAt: jwt.NewNumericDate(expires),
+			},
+		}).SignedString(key)
+		require.NoError(t, err)
+		return metadata.Pairs("authorization", "Bearer "+token)
+	}
+	expires := time.Now().Add(time.Hour)
+
+	identity, err := callAs(t, a, "/banner.BannerService/AddBanner",
+		sign(jwt.SigningMethodHS256, []byte("secret"), RoleAdmin, expires))
+	require.NoError(t, err)
+	require.Equal(t, Identity{Subject: "jwt:dashboard", Role: RoleAdmin}, identity)
+
+	_, err = callAs(t, a, "/banner.BannerService/ClickBanner",
+		sign(jwt.SigningMethodRS256, rsaKey, RoleClient, expires))
+	require.NoError(t, err)
+
+	_, err = callAs(t, a, "/banner.BannerService/AddBanner",
+		sign(jwt.SigningMethodRS256, rsaKey, RoleClient, expires))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = callAs(t, a, "/banner.BannerService/PickBanner",
+		sign(jwt.SigningMethodHS256, []byte("other"), RoleClient, expires))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = callAs(t, a, "/banner.BannerService/PickBanner",
+		sign(jwt.SigningMethodHS256, []byte("secret"), RoleClient, time.Now().Add(-time.Minute)))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// HS384 is not one of the accepted algorithms.
+	_, err = callAs(t, a, "/banner.BannerService/PickBanner",
+		sign(jwt.SigningMethodHS384, []byte("secret"), RoleClient, expires))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthJWTWithoutKey(t *testing.T) {
+	a, err := NewAuthInterceptor(nil, "", nil, "", "", 0)
+	require.NoError(t, err)
+
+	// An admin token signed with an empty HMAC key must not pass when no JWT key is configured.
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+		Role: RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "attacker",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte{})
+	require.NoError(t, err)
+
+	_, err = callAs(t, a, "/banner.BannerService/AddBanner", metadata.Pairs("authorization", "Bearer "+forged))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthJWTKeyFamily(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	a, err := NewAuthInterceptor(nil, "", publicPEM, "", "", 0)
+	require.NoError(t, err)
+
+	// With only an RSA key, HS256 tokens are rejected, even when signed with the public key.
+	for _, key := range [][]byte{{}, publicPEM} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+			Role:             RoleAdmin,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		}).SignedString(key)
+		require.NoError(t, err)
+
+		_, err = callAs(t, a, "/banner.BannerService/AddBanner", metadata.Pairs("authorization", "Bearer "+token))
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	keys := &keyStore{roles: map[string]string{
+		hashKey("admin-key"):  RoleAdmin,
+		hashKey("client-key"): RoleClient,
+	}}
+	a, err := NewAuthInterceptor(keys, "", nil, "", "", time.Minute)
+	require.NoError(t, err)
+
+	request := func(key string) error {
+		r := httptest.NewRequest("PUT", "/loglevel", nil)
+		if key != "" {
+			r.Header.Set("X-Api-Key", key)
+		}
+		return a.AuthorizeAdmin(r)
+	}
+
+	require.NoError(t, request("admin-key"))
+	require.Equal(t, codes.PermissionDenied, status.Code(request("client-key")))
+	require.Equal(t, codes.Unauthenticated, status.Code(request("unknown")))
+	require.Equal(t, codes.Unauthenticated, status.Code(request("")))
+}
@@ -0,0 +1,25 @@
+package internalgrpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storageError converts a storage error to a gRPC status error with the message prefixed by the
+// formatted action. The storage sentinel errors get their own codes, other errors are codes.Internal.
+func storageError(err error, format string, args ...interface{}) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, storage.ErrConflict):
+		code = codes.AlreadyExists
+	case errors.Is(err, storage.ErrNoBanners):
+		code = codes.FailedPrecondition
+	}
+	return status.Errorf(code, "%s: %v", fmt.Sprintf(format, args...), err)
+}
@@ -0,0 +1,28 @@
+package internalgrpc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStorageError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("%w: banner 1 is not assigned to slot 2", storage.ErrNotFound), codes.NotFound},
+		{fmt.Errorf("%w: key exists", storage.ErrConflict), codes.AlreadyExists},
+		{fmt.Errorf("%w: 100", storage.ErrNoBanners), codes.FailedPrecondition},
+		{errors.New("connection refused"), codes.Internal},
+	} {
+		err := storageError(tc.err, "failed to %s banner", "remove")
+		require.Equal(t, tc.code, status.Code(err), tc.err)
+		require.Equal(t, "failed to remove banner: "+tc.err.Error(), status.Convert(err).Message())
+	}
+}
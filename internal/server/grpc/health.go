@@ -0,0 +1,94 @@
+package internalgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthCheck reports whether a dependency of the service is available.
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name     string
+	check    HealthCheck
+	optional bool
+}
+
+// HealthChecker periodically runs the health checks and reports the result through the grpc.health.v1 service.
+// Every check is reported as a service of its own name, the overall status ("" and BannerService)
+// is SERVING only while all checks not added by AddOptionalCheck pass.
+type HealthChecker struct {
+	server   *health.Server
+	logger   *logger.Logger
+	interval time.Duration
+	checks   []namedCheck
+	failing  map[string]bool
+}
+
+func NewHealthChecker(server *health.Server, interval time.Duration, logg *logger.Logger) *HealthChecker {
+	return &HealthChecker{
+		server:   server,
+		logger:   logg,
+		interval: interval,
+		failing:  make(map[string]bool),
+	}
+}
+
+// AddCheck registers a check of a dependency the service cannot work without. Call it before Run.
+func (h *HealthChecker) AddCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// AddOptionalCheck registers a check reported only under its own name, the service works on without
+// the dependency. Call it before Run.
+func (h *HealthChecker) AddOptionalCheck(name string, check HealthCheck) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check, optional: true})
+}
+
+// Run checks the dependencies every interval until ctx is done.
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.Check(ctx)
+		}
+	}
+}
+
+// Check runs every check once and updates the serving statuses.
+func (h *HealthChecker) Check(ctx context.Context) {
+	overall := healthpb.HealthCheckResponse_SERVING
+	for _, c := range h.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, h.interval)
+		err := c.check(checkCtx)
+		cancel()
+
+		st := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+			if !c.optional {
+				overall = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+			if !h.failing[c.name] {
+				h.logger.Warning("Health check %s failed: %v", c.name, err)
+			}
+		} else if h.failing[c.name] {
+			h.logger.Info("Health check %s recovered", c.name)
+		}
+		h.failing[c.name] = err != nil
+		h.server.SetServingStatus(c.name, st)
+	}
+
+	h.server.SetServingStatus("", overall)
+	h.server.SetServingStatus(pb.BannerService_ServiceDesc.ServiceName, overall)
+}
@@ -0,0 +1,65 @@
+package internalgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthChecker(t *testing.T) {
+	server := health.NewServer()
+	checker := NewHealthChecker(server, time.Second, logger.New("error", io.Discard))
+
+	var rmqErr error
+	checker.AddCheck("postgres", func(context.Context) error { return nil })
+	checker.AddCheck("rmq", func(context.Context) error { return rmqErr })
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	checker.Check(context.Background())
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(pb.BannerService_ServiceDesc.ServiceName))
+
+	rmqErr = errors.New("rmq connection is closed")
+	checker.Check(context.Background())
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(pb.BannerService_ServiceDesc.ServiceName))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status("postgres"))
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("rmq"))
+
+	rmqErr = nil
+	checker.Check(context.Background())
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+}
+
+func TestHealthCheckerOptional(t *testing.T) {
+	server := health.NewServer()
+	checker := NewHealthChecker(server, time.Second, logger.New("error", io.Discard))
+
+	checker.AddCheck("postgres", func(context.Context) error { return nil })
+	checker.AddOptionalCheck("rmq", func(context.Context) error { return errors.New("rmq connection is closed") })
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.GetStatus()
+	}
+
+	// A failing optional check is only reported under its own name.
+	checker.Check(context.Background())
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("rmq"))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, status(pb.BannerService_ServiceDesc.ServiceName))
+}
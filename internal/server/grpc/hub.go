@@ -0,0 +1,123 @@
+package internalgrpc
+
+import (
+	"sync"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+)
+
+// EventFilter selects events by slot, banner and event type. An empty list matches everything.
+type EventFilter struct {
+	SlotIDs    []int
+	BannerIDs  []int
+	TypeEvents []string
+}
+
+func (f EventFilter) match(n storage.Notification) bool {
+	return matchAny(f.SlotIDs, n.SlotID) && matchAny(f.BannerIDs, n.BannerID) && matchAny(f.TypeEvents, n.TypeEvent)
+}
+
+func matchAny[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription receives the events matching its filter until it is cancelled or the hub is closed.
+type Subscription struct {
+	events  chan storage.Notification
+	filter  EventFilter
+	dropped uint64
+}
+
+// Events is closed when the hub is closed.
+func (s *Subscription) Events() <-chan storage.Notification {
+	return s.events
+}
+
+// EventHub fans out the events sent by ServiceServer to the WatchEvents streams.
+// Broadcasting never blocks: a subscriber that does not keep up loses events.
+type EventHub struct {
+	logger     *logger.Logger
+	bufferSize int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+func NewEventHub(bufferSize int, logg *logger.Logger) *EventHub {
+	return &EventHub{
+		logger:     logg,
+		bufferSize: bufferSize,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscription. The returned function cancels it.
+func (h *EventHub) Subscribe(filter EventFilter) (*Subscription, func()) {
+	sub := &Subscription{
+		events: make(chan storage.Notification, h.bufferSize),
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub, func() {}
+	}
+	h.subs[sub] = struct{}{}
+
+	return sub, func() { h.unsubscribe(sub) }
+}
+
+func (h *EventHub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	if sub.dropped > 0 {
+		h.logger.Warning("Event subscriber dropped %d events", sub.dropped)
+	}
+}
+
+func (h *EventHub) Broadcast(n storage.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if !sub.filter.match(n) {
+			continue
+		}
+		select {
+		case sub.events <- n:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Close ends every subscription, so the WatchEvents streams return and the server can stop gracefully.
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	subs := make([]*Subscription, 0, len(h.subs))
+	for sub := range h.subs {
+		subs = append(subs, sub)
+	}
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		h.unsubscribe(sub)
+	}
+}
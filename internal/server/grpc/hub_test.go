@@ -0,0 +1,79 @@
+package internalgrpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/publisher"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestWatchEvents(t *testing.T) {
+	logg := logger.New("error", io.Discard)
+	hub := NewEventHub(16, logg)
+	api := NewEventServiceServer(nil, &publisher.Noop{}, logg)
+	api.SetEventHub(hub)
+
+	validation := NewValidationInterceptor()
+	server := grpc.NewServer(grpc.ChainStreamInterceptor(validation.StreamServerInterceptor))
+	pb.RegisterBannerServiceServer(server, api)
+	listener := bufconn.Listen(1 << 16)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewBannerServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invalid, err := client.WatchEvents(ctx, &pb.WatchEventsRequest{TypeEvents: []string{"unknown"}})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err := client.WatchEvents(ctx, &pb.WatchEventsRequest{SlotIds: []int32{2}, TypeEvents: []string{"click"}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		return len(hub.subs) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	for _, n := range []storage.Notification{
+		{TypeEvent: storage.EventClick, SlotID: 1, BannerID: 1, UsergroupID: 1},
+		{TypeEvent: storage.EventImpress, SlotID: 2, BannerID: 1, UsergroupID: 1},
+		{TypeEvent: storage.EventClick, SlotID: 2, BannerID: 3, UsergroupID: 4, DateTime: time.Now()},
+	} {
+		require.NoError(t, api.sendNotification(ctx, n))
+	}
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "click", event.GetTypeEvent())
+	require.Equal(t, int32(2), event.GetSlotId())
+	require.Equal(t, int32(3), event.GetBannerId())
+
+	// Closing the hub ends the streams.
+	hub.Close()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -2,11 +2,12 @@ package internalgrpc
 
 import (
 	"context"
-	"fmt"
+	"math/rand"
 	"strings"
 	"time"
 
 	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -14,23 +15,43 @@ import (
 )
 
 type LoggingInterceptor struct {
-	logger *logger.Logger
+	logger   *logger.Logger
+	redactor redactor
+	sampling map[string]float64
 }
 
-func NewLoggingInterceptor(logg *logger.Logger) *LoggingInterceptor {
+// NewLoggingInterceptor creates the interceptor. The fields named in redactFields are masked in the logged
+// requests and responses. sampling is the share of the calls logged, from 0 to 1, keyed by the method name
+// case-insensitively; the calls of other methods and the failed calls are always logged.
+func NewLoggingInterceptor(logg *logger.Logger, redactFields []string, sampling map[string]float64) *LoggingInterceptor {
+	rates := make(map[string]float64, len(sampling))
+	for method, rate := range sampling {
+		rates[strings.ToLower(method)] = rate
+	}
+
 	return &LoggingInterceptor{
-		logger: logg,
+		logger:   logg,
+		redactor: newRedactor(redactFields),
+		sampling: rates,
 	}
 }
 
-func (l *LoggingInterceptor) logRequest(ctx context.Context, method string, req interface{}) {
-	_ = ctx
-	l.logger.Info(fmt.Sprintf("Received request: method=%s, request=%v", method, req))
+// sampled reports whether the call is logged on success.
+func (l *LoggingInterceptor) sampled(fullMethod string) bool {
+	rate, ok := l.sampling[strings.ToLower(methodFromFullMethod(fullMethod))]
+	if !ok {
+		return true
+	}
+	return rand.Float64() < rate //nolint:gosec
 }
 
-func (l *LoggingInterceptor) logResponse(ctx context.Context, method string, resp interface{}) {
-	_ = ctx
-	l.logger.Info("Sent %s response: %+v", method, resp)
+// requestLogger returns the logger of a call, adding the fields identifying the call to every message.
+// The request ID is added by the context-aware methods.
+func (l *LoggingInterceptor) requestLogger(ctx context.Context, fullMethod string) *logger.Logger {
+	return l.logger.With(
+		"method", methodFromFullMethod(fullMethod),
+		"peer", getIP(ctx),
+	)
 }
 
 func (l *LoggingInterceptor) UnaryServerInterceptor(
@@ -38,31 +59,99 @@ func (l *LoggingInterceptor) UnaryServerInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
-	l.logRequest(ctx, info.FullMethod, req)
+	logg := l.requestLogger(ctx, info.FullMethod)
+	sampled := l.sampled(info.FullMethod)
+	if sampled {
+		logg.InfoContext(ctx, "Received request: %v", l.redactor.redact(req))
+	}
 	startTime := time.Now()
 	resp, err := handler(ctx, req)
 	latency := time.Since(startTime)
 
-	statusCode := 0
 	if err != nil {
-		statusCode = int(status.Code(err))
-		l.logger.Error("Error: %v", err)
-	} else {
-		l.logResponse(ctx, info.FullMethod, resp)
+		if !sampled {
+			// The request of a failed call is logged whatever the sampling.
+			logg.InfoContext(ctx, "Received request: %v", l.redactor.redact(req))
+		}
+		logg.ErrorContext(ctx, "Error: %v", err)
+	} else if sampled {
+		logg.InfoContext(ctx, "Sent response: %+v", l.redactor.redact(resp))
 	}
 
-	l.logger.Info("INFO [%s] { ClientIPAddress:%s user-agent:%s StartAt:%s gRPCMethod:%s StatusCode:%d Latency:%s}",
-		time.Now().Format("2006-01-02 15:04:05"),
-		getIP(ctx),
-		getUserAgent(ctx),
-		startTime.Format("2006-01-02 15:04:05.999999 -0700 MST"),
-		methodFromFullMethod(info.FullMethod),
-		statusCode,
-		latency,
-	)
+	if sampled || err != nil {
+		logSummary(ctx, logg, "Finished call", startTime, latency, err)
+	}
 	return resp, err
 }
 
+func (l *LoggingInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx := ss.Context()
+	logg := l.requestLogger(ctx, info.FullMethod)
+	sampled := l.sampled(info.FullMethod)
+	if sampled {
+		logg.InfoContext(ctx, "Started stream")
+	}
+	startTime := time.Now()
+	stream := &loggingStream{ServerStream: ss}
+	err := handler(srv, stream)
+	latency := time.Since(startTime)
+
+	if err != nil {
+		logg.ErrorContext(ctx, "Error: %v", err)
+	}
+
+	if sampled || err != nil {
+		logSummary(ctx, logg.With("received", stream.received, "sent", stream.sent),
+			"Finished stream", startTime, latency, err)
+	}
+	return err
+}
+
+// logSummary logs the outcome of a call with its status code and latency as fields.
+func logSummary(
+	ctx context.Context,
+	logg *logger.Logger,
+	message string,
+	startTime time.Time,
+	latency time.Duration,
+	err error,
+) {
+	logg.With(
+		"status", status.Code(err).String(),
+		"latency_ms", float64(latency.Microseconds())/1000,
+		"start_at", startTime.UTC().Format(time.RFC3339Nano),
+		"user_agent", getUserAgent(ctx),
+	).InfoContext(ctx, message)
+}
+
+// loggingStream counts the messages of a stream.
+type loggingStream struct {
+	grpc.ServerStream
+	sent     int
+	received int
+}
+
+func (s *loggingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent++
+	}
+	return err
+}
+
+func (s *loggingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received++
+	}
+	return err
+}
+
 func getIP(ctx context.Context) string {
 	var clientIP string
 	peer, ok := peer.FromContext(ctx)
@@ -82,11 +171,30 @@ func methodFromFullMethod(fullMethod string) string {
 	return parts[len(parts)-1]
 }
 
+// bannerServiceMethod returns the method name if fullMethod is a BannerService method.
+func bannerServiceMethod(fullMethod string) (string, bool) {
+	prefix := "/" + pb.BannerService_ServiceDesc.ServiceName + "/"
+	if !strings.HasPrefix(fullMethod, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(fullMethod, prefix), true
+}
+
 func getUserAgent(ctx context.Context) string {
 	md, ok := metadata.FromIncomingContext(ctx)
 	userAgent := "unknown"
-	if ok {
+	if ok && len(md["user-agent"]) > 0 {
 		userAgent = md["user-agent"][0]
 	}
 	return userAgent
 }
+
+// contextStream is a server stream with a context derived by an interceptor.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
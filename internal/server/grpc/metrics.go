@@ -0,0 +1,46 @@
+package internalgrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsInterceptor counts the requests by method and status code and observes their latency.
+type MetricsInterceptor struct{}
+
+func NewMetricsInterceptor() *MetricsInterceptor {
+	return &MetricsInterceptor{}
+}
+
+func (m *MetricsInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	startTime := time.Now()
+	resp, err := handler(ctx, req)
+	observe(info.FullMethod, startTime, err)
+	return resp, err
+}
+
+func (m *MetricsInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	startTime := time.Now()
+	err := handler(srv, ss)
+	observe(info.FullMethod, startTime, err)
+	return err
+}
+
+func observe(fullMethod string, startTime time.Time, err error) {
+	method := methodFromFullMethod(fullMethod)
+	metrics.RPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.RPCDuration.WithLabelValues(method).Observe(time.Since(startTime).Seconds())
+}
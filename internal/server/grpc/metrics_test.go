@@ -0,0 +1,34 @@
+package internalgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	m := NewMetricsInterceptor()
+	call := func(err error) {
+		_, _ = m.UnaryServerInterceptor(context.Background(), nil,
+			&grpc.UnaryServerInfo{FullMethod: "/banner.BannerService/RemoveBanner"},
+			func(context.Context, interface{}) (interface{}, error) { return nil, err })
+	}
+
+	ok := metrics.RPCRequests.WithLabelValues("RemoveBanner", codes.OK.String())
+	notFound := metrics.RPCRequests.WithLabelValues("RemoveBanner", codes.NotFound.String())
+	okBefore, notFoundBefore := testutil.ToFloat64(ok), testutil.ToFloat64(notFound)
+
+	call(nil)
+	call(nil)
+	call(status.Error(codes.NotFound, "not found"))
+
+	require.Equal(t, okBefore+2, testutil.ToFloat64(ok))
+	require.Equal(t, notFoundBefore+1, testutil.ToFloat64(notFound))
+	require.Positive(t, testutil.CollectAndCount(metrics.RPCDuration, "banner_grpc_request_duration_seconds"))
+}
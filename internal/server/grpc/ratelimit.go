@@ -0,0 +1,195 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// gatewayPeer is the peer address of calls made by the HTTP gateway through the in-process connection.
+const gatewayPeer = "bufconn"
+
+// RateLimit is a token bucket: Rate requests per second with bursts of up to Burst requests.
+// A Rate of zero or less means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type limiterKey struct {
+	method string
+	client string
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimitInterceptor limits the BannerService calls of every client, per method.
+// A client is the authenticated identity, or the peer IP for anonymous calls. For calls of the HTTP gateway
+// the client IP is the last address of x-forwarded-for, which the gateway appends itself.
+type RateLimitInterceptor struct {
+	defaultLimit RateLimit
+	methodLimits map[string]RateLimit
+	idleTimeout  time.Duration
+	perIP        bool // All the calls of an IP share defaultLimit, whoever authenticates.
+
+	mu        sync.Mutex
+	limiters  map[limiterKey]*clientLimiter
+	lastSweep time.Time
+}
+
+// NewRateLimitInterceptor creates the interceptor. Method names in methodLimits are case-insensitive,
+// the limiters of clients idle for idleTimeout are dropped.
+func NewRateLimitInterceptor(
+	defaultLimit RateLimit,
+	methodLimits map[string]RateLimit,
+	idleTimeout time.Duration,
+) *RateLimitInterceptor {
+	limits := make(map[string]RateLimit, len(methodLimits))
+	for method, limit := range methodLimits {
+		limits[strings.ToLower(method)] = limit
+	}
+
+	return &RateLimitInterceptor{
+		defaultLimit: defaultLimit,
+		methodLimits: limits,
+		idleTimeout:  idleTimeout,
+		limiters:     make(map[limiterKey]*clientLimiter),
+		lastSweep:    time.Now(),
+	}
+}
+
+// NewIPRateLimitInterceptor creates an interceptor limiting all the BannerService calls of every client IP.
+// It goes before the auth interceptor, so that floods of unauthenticated calls are rejected before
+// their credentials are looked up.
+func NewIPRateLimitInterceptor(limit RateLimit, idleTimeout time.Duration) *RateLimitInterceptor {
+	l := NewRateLimitInterceptor(limit, nil, idleTimeout)
+	l.perIP = true
+	return l
+}
+
+func (l *RateLimitInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := l.limit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *RateLimitInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := l.limit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (l *RateLimitInterceptor) limit(ctx context.Context, fullMethod string) error {
+	method, ok := bannerServiceMethod(fullMethod)
+	if !ok {
+		return nil
+	}
+
+	var key limiterKey
+	limit, ok := l.methodLimits[strings.ToLower(method)]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if l.perIP {
+		ip, _ := clientIP(ctx)
+		key = limiterKey{client: "ip:" + ip}
+	} else {
+		key = limiterKey{method: method, client: clientKey(ctx)}
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	if !l.limiter(key, limit).Allow() {
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s, retry later", method)
+	}
+	return nil
+}
+
+func (l *RateLimitInterceptor) limiter(key limiterKey, limit RateLimit) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > l.idleTimeout {
+		for k, cl := range l.limiters {
+			if now.Sub(cl.lastSeen) > l.idleTimeout {
+				delete(l.limiters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	cl, ok := l.limiters[key]
+	if !ok {
+		burst := limit.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		cl = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(limit.Rate), burst)}
+		l.limiters[key] = cl
+	}
+	cl.lastSeen = now
+	return cl.limiter
+}
+
+// clientKey identifies the client of the call for rate limiting.
+func clientKey(ctx context.Context) string {
+	ip, viaGateway := clientIP(ctx)
+	identity, ok := IdentityFromContext(ctx)
+	switch {
+	case !ok:
+		return "ip:" + ip
+	case viaGateway:
+		// The gateway may call with one key on behalf of many browsers, e.g. for tracking links.
+		return identity.Subject + "@" + ip
+	default:
+		return identity.Subject
+	}
+}
+
+// clientIP returns the IP of the client and whether the call came through the HTTP gateway.
+func clientIP(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	if p.Addr.String() == gatewayPeer {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			return strings.TrimSpace(hops[len(hops)-1]), true
+		}
+		return gatewayPeer, true
+	}
+
+	host, _, err := net.SplitHostPort(getIP(ctx))
+	if err != nil {
+		return getIP(ctx), false
+	}
+	return host, false
+}
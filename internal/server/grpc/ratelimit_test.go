@@ -0,0 +1,94 @@
+package internalgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type gatewayAddr struct{}
+
+func (gatewayAddr) Network() string { return "bufconn" }
+func (gatewayAddr) String() string  { return "bufconn" }
+
+func TestRateLimit(t *testing.T) {
+	l := NewRateLimitInterceptor(
+		RateLimit{Rate: 0.001, Burst: 3},
+		map[string]RateLimit{"clickbanner": {Rate: 0.001, Burst: 1}, "AddBanner": {}},
+		time.Minute,
+	)
+
+	call := func(ctx context.Context, method string) error {
+		_, err := l.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+	fromIP := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+	}
+
+	client := fromIP("10.0.0.1")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, call(client, "/banner.BannerService/PickBanner"))
+	}
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(client, "/banner.BannerService/PickBanner")))
+
+	// Other clients and other methods have their own buckets.
+	require.NoError(t, call(fromIP("10.0.0.2"), "/banner.BannerService/PickBanner"))
+	require.NoError(t, call(client, "/banner.BannerService/ClickBanner"))
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(client, "/banner.BannerService/ClickBanner")))
+
+	// A zero rate means no limit, and other services are not limited.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, call(client, "/banner.BannerService/AddBanner"))
+		require.NoError(t, call(client, "/grpc.health.v1.Health/Check"))
+	}
+
+	// An authenticated client is limited by its identity, whatever its address.
+	identity := context.WithValue(fromIP("10.0.0.3"), identityKey{}, Identity{Subject: "apikey:1", Role: RoleClient})
+	require.NoError(t, call(identity, "/banner.BannerService/ClickBanner"))
+	identity = context.WithValue(fromIP("10.0.0.4"), identityKey{}, Identity{Subject: "apikey:1", Role: RoleClient})
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(identity, "/banner.BannerService/ClickBanner")))
+
+	// Calls of the HTTP gateway are limited by the address the gateway appended to x-forwarded-for.
+	viaGateway := func(forwardedFor string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: gatewayAddr{}})
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwardedFor))
+		return context.WithValue(ctx, identityKey{}, Identity{Subject: "apikey:tracking", Role: RoleClient})
+	}
+	require.NoError(t, call(viaGateway("1.1.1.1, 192.168.0.1"), "/banner.BannerService/ClickBanner"))
+	require.NoError(t, call(viaGateway("1.1.1.1, 192.168.0.2"), "/banner.BannerService/ClickBanner"))
+	require.Equal(t, codes.ResourceExhausted,
+		status.Code(call(viaGateway("2.2.2.2, 192.168.0.1"), "/banner.BannerService/ClickBanner")))
+}
+
+func TestIPRateLimit(t *testing.T) {
+	l := NewIPRateLimitInterceptor(RateLimit{Rate: 0.001, Burst: 2}, time.Minute)
+
+	call := func(ctx context.Context, method string) error {
+		_, err := l.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+	fromIP := func(ip string, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+
+	// All the methods share the bucket of the IP, whatever the credentials sent.
+	client := fromIP("10.0.0.1", metadata.Pairs("x-api-key", "one"))
+	require.NoError(t, call(client, "/banner.BannerService/PickBanner"))
+	require.NoError(t, call(fromIP("10.0.0.1", metadata.Pairs("x-api-key", "two")), "/banner.BannerService/ClickBanner"))
+	require.Equal(t, codes.ResourceExhausted, status.Code(call(client, "/banner.BannerService/AddBanner")))
+
+	require.NoError(t, call(fromIP("10.0.0.2", nil), "/banner.BannerService/PickBanner"))
+	require.NoError(t, call(client, "/grpc.health.v1.Health/Check"))
+}
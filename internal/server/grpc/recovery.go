@@ -0,0 +1,55 @@
+package internalgrpc
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor turns a panic in a handler into a codes.Internal error, so it does not
+// take down the process. The panic value and the stack trace are logged, not sent to the client.
+type RecoveryInterceptor struct {
+	logger *logger.Logger
+}
+
+func NewRecoveryInterceptor(logg *logger.Logger) *RecoveryInterceptor {
+	return &RecoveryInterceptor{
+		logger: logg,
+	}
+}
+
+func (r *RecoveryInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recovered(ctx, info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (r *RecoveryInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recovered(ss.Context(), info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func (r *RecoveryInterceptor) recovered(ctx context.Context, fullMethod string, p interface{}) error {
+	r.logger.ErrorContext(ctx, "Panic in %s: %v\n%s", fullMethod, p, debug.Stack())
+	return status.Errorf(codes.Internal, "internal error")
+}
@@ -0,0 +1,65 @@
+package internalgrpc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []interface{}
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testStream) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+func TestRecoveryUnary(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRecoveryInterceptor(logger.New("error", &out))
+
+	_, err := r.UnaryServerInterceptor(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/banner.BannerService/PickBanner"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panic("boom")
+		})
+
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.NotContains(t, err.Error(), "boom")
+	require.Contains(t, out.String(), "Panic in /banner.BannerService/PickBanner: boom")
+	require.Contains(t, out.String(), "recovery.go")
+}
+
+func TestRecoveryAndLoggingStream(t *testing.T) {
+	var out bytes.Buffer
+	logg := logger.New("info", &out)
+	r := NewRecoveryInterceptor(logg)
+	l := NewLoggingInterceptor(logg, nil, nil)
+	info := &grpc.StreamServerInfo{FullMethod: "/banner.BannerService/Watch", IsServerStream: true}
+
+	err := l.StreamServerInterceptor(nil, &testStream{ctx: context.Background()}, info,
+		func(srv interface{}, ss grpc.ServerStream) error {
+			return r.StreamServerInterceptor(srv, ss, info, func(_ interface{}, ss grpc.ServerStream) error {
+				_ = ss.SendMsg("first")
+				_ = ss.SendMsg("second")
+				panic("boom")
+			})
+		})
+
+	require.Equal(t, codes.Internal, status.Code(err))
+	require.Contains(t, out.String(), "Finished stream method=Watch")
+	require.Contains(t, out.String(), "received=0 sent=2 status=Internal")
+}
@@ -0,0 +1,77 @@
+package internalgrpc
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const redactedValue = "[REDACTED]"
+
+// redactor masks the configured fields of the logged messages. Fields are matched by their proto name,
+// case-insensitively, at any depth. String fields are replaced by redactedValue, other fields are cleared.
+type redactor struct {
+	fields map[string]struct{}
+}
+
+func newRedactor(fields []string) redactor {
+	r := redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = struct{}{}
+	}
+	return r
+}
+
+// redact returns a copy of m with the configured fields masked. Values other than proto messages are returned as is.
+func (r redactor) redact(m interface{}) interface{} {
+	msg, ok := m.(proto.Message)
+	if !ok || len(r.fields) == 0 {
+		return m
+	}
+	msg = proto.Clone(msg)
+	r.redactMessage(msg.ProtoReflect())
+	return msg
+}
+
+func (r redactor) redactMessage(m protoreflect.Message) {
+	// The fields are collected first, the message is not modified while ranging over it.
+	var populated []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		populated = append(populated, fd)
+		return true
+	})
+
+	for _, fd := range populated {
+		if _, ok := r.fields[strings.ToLower(string(fd.Name()))]; ok {
+			r.mask(m, fd)
+			continue
+		}
+		if fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind || fd.IsMap() {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				r.redactMessage(list.Get(i).Message())
+			}
+			continue
+		}
+		r.redactMessage(m.Mutable(fd).Message())
+	}
+}
+
+func (r redactor) mask(m protoreflect.Message, fd protoreflect.FieldDescriptor) {
+	if fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+		m.Clear(fd)
+		return
+	}
+	if fd.IsList() {
+		list := m.Mutable(fd).List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, protoreflect.ValueOfString(redactedValue))
+		}
+		return
+	}
+	m.Set(fd, protoreflect.ValueOfString(redactedValue))
+}
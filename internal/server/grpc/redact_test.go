@@ -0,0 +1,72 @@
+package internalgrpc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRedactor(t *testing.T) {
+	r := newRedactor([]string{"Click_Token", "impression_id"})
+	resp := &pb.PickBannerResponse{BannerId: 1, ClickToken: "1.2.3.sig", ImpressionId: 7, BeaconToken: "beacon"}
+
+	redacted, ok := r.redact(resp).(*pb.PickBannerResponse)
+	require.True(t, ok)
+	require.Equal(t, redactedValue, redacted.GetClickToken())
+	require.Zero(t, redacted.GetImpressionId())
+	require.Equal(t, "beacon", redacted.GetBeaconToken())
+	require.Equal(t, int32(1), redacted.GetBannerId())
+
+	// The logged message is a copy.
+	require.Equal(t, "1.2.3.sig", resp.GetClickToken())
+	require.Equal(t, int32(7), resp.GetImpressionId())
+
+	require.Equal(t, "not a message", r.redact("not a message"))
+	require.Nil(t, r.redact(nil))
+}
+
+func TestLoggingRedaction(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLoggingInterceptor(logger.New("info", &out), []string{"click_token"}, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/banner.BannerService/PickBanner"}
+
+	_, err := l.UnaryServerInterceptor(context.Background(), &pb.PickBannerRequest{SlotId: 1, UsergroupId: 2}, info,
+		func(context.Context, interface{}) (interface{}, error) {
+			return &pb.PickBannerResponse{BannerId: 3, ClickToken: "1.2.3.sig"}, nil
+		})
+
+	require.NoError(t, err)
+	require.Contains(t, out.String(), redactedValue)
+	require.NotContains(t, out.String(), "1.2.3.sig")
+}
+
+func TestLoggingSampling(t *testing.T) {
+	var out bytes.Buffer
+	l := NewLoggingInterceptor(logger.New("info", &out), nil, map[string]float64{"pickbanner": 0})
+	call := func(method string, err error) {
+		_, _ = l.UnaryServerInterceptor(context.Background(), &pb.PickBannerRequest{SlotId: 1, UsergroupId: 2},
+			&grpc.UnaryServerInfo{FullMethod: "/banner.BannerService/" + method},
+			func(context.Context, interface{}) (interface{}, error) {
+				return &pb.PickBannerResponse{}, err
+			})
+	}
+
+	call("PickBanner", nil)
+	require.Empty(t, out.String())
+
+	call("ClickBanner", nil)
+	require.Contains(t, out.String(), "Finished call method=ClickBanner")
+
+	out.Reset()
+	call("PickBanner", status.Error(codes.NotFound, "no banner"))
+	require.Contains(t, out.String(), "Received request")
+	require.Contains(t, out.String(), "no banner")
+	require.Contains(t, out.String(), "Finished call method=PickBanner")
+}
@@ -0,0 +1,47 @@
+package internalgrpc
+
+import (
+	"context"
+
+	"github.com/cronnoss/banners-rotation/internal/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDInterceptor stores the x-request-id sent by the client in the context, or a new one if
+// it is missing or invalid, and returns it in the response headers.
+type RequestIDInterceptor struct{}
+
+func NewRequestIDInterceptor() *RequestIDInterceptor {
+	return &RequestIDInterceptor{}
+}
+
+func (i *RequestIDInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	id := incomingRequestID(ctx)
+	// The header is sent with the response, an error only means that the client is gone.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+	return handler(requestid.NewContext(ctx, id), req)
+}
+
+func (i *RequestIDInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	id := incomingRequestID(ss.Context())
+	_ = ss.SetHeader(metadata.Pairs(requestid.Header, id))
+	return handler(srv, &contextStream{ServerStream: ss, ctx: requestid.NewContext(ss.Context(), id)})
+}
+
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestid.Header); len(ids) > 0 && requestid.Valid(ids[0]) {
+		return ids[0]
+	}
+	return requestid.New()
+}
@@ -0,0 +1,64 @@
+package internalgrpc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/requestid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// headerStream records the headers set by the interceptors.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRequestID(t *testing.T) {
+	var out bytes.Buffer
+	logg := logger.New("info", &out)
+	info := &grpc.UnaryServerInfo{FullMethod: "/banner.BannerService/PickBanner"}
+	call := func(md metadata.MD) (string, metadata.MD) {
+		stream := &headerStream{}
+		ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), stream)
+		var id string
+		_, err := NewRequestIDInterceptor().UnaryServerInterceptor(ctx, nil, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				id = requestid.FromContext(ctx)
+				return NewLoggingInterceptor(logg, nil, nil).UnaryServerInterceptor(ctx, req, info,
+					func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+			})
+		require.NoError(t, err)
+		return id, stream.header
+	}
+
+	// The ID of the client is kept and returned.
+	id, header := call(metadata.Pairs("x-request-id", "req-1"))
+	require.Equal(t, "req-1", id)
+	require.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	// Every line logged for the call carries it.
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		require.Contains(t, string(line), "request_id=req-1")
+	}
+
+	// A missing or invalid ID is replaced.
+	id, header = call(metadata.MD{})
+	require.True(t, requestid.Valid(id))
+	require.Equal(t, []string{id}, header.Get("x-request-id"))
+
+	id, _ = call(metadata.Pairs("x-request-id", "two words"))
+	require.NotEqual(t, "two words", id)
+	require.True(t, requestid.Valid(id))
+}
@@ -2,58 +2,70 @@ package internalgrpc
 
 import (
 	"context"
-	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/cronnoss/banners-rotation/interfaces"
+	"github.com/cronnoss/banners-rotation/internal/clicktoken"
 	"github.com/cronnoss/banners-rotation/internal/logger"
-	"github.com/cronnoss/banners-rotation/internal/rmq"
+	"github.com/cronnoss/banners-rotation/internal/metrics"
+	"github.com/cronnoss/banners-rotation/internal/publisher"
 	"github.com/cronnoss/banners-rotation/internal/server/pb"
 	"github.com/cronnoss/banners-rotation/internal/storage"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type ServiceServer struct {
-	storage      interfaces.Storage
-	eventsProdMq *rmq.Rmq
-	logger       *logger.Logger
+	storage     interfaces.Storage
+	publisher   interfaces.EventPublisher
+	logger      *logger.Logger
+	clickTokens *clicktoken.Signer
+	hub         *EventHub
 	pb.UnimplementedBannerServiceServer
 }
 
-func NewEventServiceServer(storage interfaces.Storage, eventsProdMq *rmq.Rmq, log *logger.Logger) *ServiceServer {
+func NewEventServiceServer(
+	storage interfaces.Storage,
+	publisher interfaces.EventPublisher,
+	log *logger.Logger,
+) *ServiceServer {
 	return &ServiceServer{
-		storage:      storage,
-		eventsProdMq: eventsProdMq,
-		logger:       log,
+		storage:   storage,
+		publisher: publisher,
+		logger:    log,
 	}
 }
 
+// SetClickTokenSigner makes PickBanner return tokens for the HTTP click-redirect endpoint.
+func (s *ServiceServer) SetClickTokenSigner(signer *clicktoken.Signer) {
+	s.clickTokens = signer
+}
+
+// SetEventHub enables the WatchEvents stream, fed with every event sent by the server.
+func (s *ServiceServer) SetEventHub(hub *EventHub) {
+	s.hub = hub
+}
+
 func (s *ServiceServer) AddBanner(ctx context.Context, req *pb.AddBannerRequest) (*pb.AddBannerResponse, error) {
 	bannerID := int(req.GetBannerId())
 	slotID := int(req.GetSlotId())
 
-	// Checking for a non-existent banner
-	if !s.bannerExists(ctx, bannerID) {
-		return nil, status.Errorf(codes.NotFound, "specified banner does not exist")
-	}
-
-	// Checking for a non-existent slot
-	if !s.slotExists(ctx, slotID) {
-		return nil, status.Errorf(codes.NotFound, "specified slot does not exist")
+	// Checking for a non-existent banner or slot
+	if err := s.checkExistence(ctx, bannerID, slotID, 0); err != nil {
+		return nil, err
 	}
 
 	// Checking for re-adding a banner to a slot
 	if exists, err := s.checkDuplicateBannerSlot(ctx, bannerID, slotID); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to check duplicate: %v", err)
+		return nil, storageError(err, "failed to check duplicate")
 	} else if exists {
 		return nil, status.Errorf(codes.AlreadyExists, "banner is already assigned to the slot")
 	}
 
 	// Adding a banner to a slot
 	if err := s.storage.AddBanner(ctx, bannerID, slotID); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to add banner: %v", err)
+		return nil, storageError(err, "failed to add banner")
 	}
 
 	return &pb.AddBannerResponse{Message: "Banner added successfully"}, nil
@@ -63,16 +75,23 @@ func (s *ServiceServer) checkDuplicateBannerSlot(ctx context.Context, bannerID,
 	return s.storage.IsBannerAssignedToSlot(ctx, bannerID, slotID)
 }
 
-func (s *ServiceServer) bannerExists(ctx context.Context, bannerID int) bool {
-	return s.storage.BannerExists(ctx, bannerID)
-}
-
-func (s *ServiceServer) slotExists(ctx context.Context, slotID int) bool {
-	return s.storage.SlotExists(ctx, slotID)
-}
+// checkExistence returns a NotFound error if the banner, the slot or the user group does not exist.
+// The user group is not checked if userGroupID is 0.
+func (s *ServiceServer) checkExistence(ctx context.Context, bannerID, slotID, userGroupID int) error {
+	existence, err := s.storage.Exist(ctx, bannerID, slotID, userGroupID)
+	if err != nil {
+		return storageError(err, "failed to check existence")
+	}
 
-func (s *ServiceServer) userGroupExists(ctx context.Context, userGroupID int) bool {
-	return s.storage.UserGroupExists(ctx, userGroupID)
+	switch {
+	case !existence.Banner:
+		return status.Errorf(codes.NotFound, "specified banner does not exist")
+	case !existence.Slot:
+		return status.Errorf(codes.NotFound, "specified slot does not exist")
+	case userGroupID != 0 && !existence.UserGroup:
+		return status.Errorf(codes.NotFound, "specified userGroup does not exist")
+	}
+	return nil
 }
 
 func (s *ServiceServer) RemoveBanner(
@@ -80,7 +99,7 @@ func (s *ServiceServer) RemoveBanner(
 	req *pb.RemoveBannerRequest,
 ) (*pb.RemoveBannerResponse, error) {
 	if err := s.storage.RemoveBanner(ctx, int(req.GetBannerId()), int(req.GetSlotId())); err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to remove banner: %v", err)
+		return nil, storageError(err, "failed to remove banner")
 	}
 	return &pb.RemoveBannerResponse{Message: "Banner removed successfully"}, nil
 }
@@ -90,34 +109,47 @@ func (s *ServiceServer) ClickBanner(ctx context.Context, req *pb.ClickBannerRequ
 	slotID := int(req.GetSlotId())
 	userGroupID := int(req.GetUsergroupId())
 
-	// Checking for a non-existent banner
-	if !s.bannerExists(ctx, bannerID) {
-		return nil, status.Errorf(codes.NotFound, "specified banner does not exist")
+	// Checking for a non-existent banner, slot or userGroup
+	if err := s.checkExistence(ctx, bannerID, slotID, userGroupID); err != nil {
+		return nil, err
 	}
 
-	// Checking for a non-existent slot
-	if !s.slotExists(ctx, slotID) {
-		return nil, status.Errorf(codes.NotFound, "specified slot does not exist")
+	if req.GetPreview() {
+		targetURL, err := s.storage.BannerTargetURL(ctx, bannerID)
+		if err != nil {
+			return nil, storageError(err, "failed to get banner target URL")
+		}
+		return &pb.ClickBannerResponse{Message: "Banner click previewed", TargetUrl: targetURL}, nil
 	}
 
-	// Checking for a non-existent userGroup
-	if !s.userGroupExists(ctx, userGroupID) {
-		return nil, status.Errorf(codes.NotFound, "specified userGroup does not exist")
+	var click *storage.Click
+	var err error
+	if impressionID := int(req.GetImpressionId()); impressionID != 0 {
+		click, err = s.storage.ClickImpression(ctx, impressionID, bannerID, slotID, userGroupID)
+	} else {
+		click, err = s.storage.ClickBanner(ctx, bannerID, slotID, userGroupID)
 	}
-
-	click, err := s.storage.ClickBanner(ctx, bannerID, slotID, userGroupID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to click banner: %v", err)
+		return nil, storageError(err, "failed to click banner")
 	}
 
+	metrics.Clicks.WithLabelValues(strconv.Itoa(slotID), strconv.Itoa(bannerID)).Inc()
+
 	// Sending a notification to a queue
 	notification := createClickNotification(click)
-	err = s.sendNotification(notification)
+	err = s.sendNotification(ctx, notification)
 
 	if err != nil {
-		s.logger.Error("Failed to RMQ: %v", err)
+		s.logger.ErrorContext(ctx, "Failed to publish event: %v", err)
 	}
-	return &pb.ClickBannerResponse{Message: "Banner clicked successfully"}, nil
+
+	// The click is already recorded, so a missing target URL does not fail the request.
+	targetURL, err := s.storage.BannerTargetURL(ctx, bannerID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get banner target URL: %v", err)
+	}
+
+	return &pb.ClickBannerResponse{Message: "Banner clicked successfully", TargetUrl: targetURL}, nil
 }
 
 func (s *ServiceServer) PickBanner(ctx context.Context, req *pb.PickBannerRequest) (*pb.PickBannerResponse, error) {
@@ -126,36 +158,104 @@ func (s *ServiceServer) PickBanner(ctx context.Context, req *pb.PickBannerReques
 
 	impress, bannerID, err := s.storage.PickBanner(ctx, slotID, userGroupID)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to pick banner: %v", err)
+		return nil, storageError(err, "failed to pick banner")
 	}
 
+	metrics.Picks.WithLabelValues(strconv.Itoa(slotID), strconv.Itoa(bannerID)).Inc()
+
 	// Отправка уведомления в очередь
 	notification := createImpressNotification(impress)
-	err = s.sendNotification(notification)
+	if impress.Status == storage.ImpressStatusServed {
+		notification = createServeNotification(impress)
+	}
+	err = s.sendNotification(ctx, notification)
 
 	if err != nil {
-		s.logger.Error("Failed to RMQ: %v", err)
+		s.logger.ErrorContext(ctx, "Failed to publish event: %v", err)
+	}
+
+	resp := &pb.PickBannerResponse{
+		BannerId:     int32(bannerID),
+		Message:      "Banner picked successfully",
+		ImpressionId: int32(impress.ID),
+	}
+	if s.clickTokens != nil {
+		resp.ClickToken = s.clickTokens.Sign(clicktoken.Click{
+			BannerID:     int32(bannerID),
+			SlotID:       int32(slotID),
+			UserGroupID:  int32(userGroupID),
+			ImpressionID: int32(impress.ID),
+		})
+		if impress.Status == storage.ImpressStatusServed {
+			resp.BeaconToken = s.clickTokens.SignImpression(int32(impress.ID))
+		}
 	}
 
-	return &pb.PickBannerResponse{BannerId: int32(bannerID), Message: "Banner picked successfully"}, nil
+	return resp, nil
 }
 
-func (s *ServiceServer) sendNotification(notification storage.Notification) error {
-	notificationJSON, err := serializeNotification(notification)
+func (s *ServiceServer) ConfirmImpression(
+	ctx context.Context,
+	req *pb.ConfirmImpressionRequest,
+) (*pb.ConfirmImpressionResponse, error) {
+	impress, err := s.storage.ConfirmImpression(ctx, int(req.GetImpressionId()))
 	if err != nil {
-		s.logger.Error("Failed to serialize notification: %v", err)
-		return err
+		return nil, storageError(err, "failed to confirm impression %d", req.GetImpressionId())
 	}
 
-	err = publishNotificationToRMQ(notificationJSON, s.eventsProdMq)
-	if err != nil {
-		s.logger.Error("Failed to publish notification to RMQ: %v", err)
+	notification := createImpressNotification(impress)
+	if err := s.sendNotification(ctx, notification); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to publish event: %v", err)
+	}
+
+	return &pb.ConfirmImpressionResponse{Message: "Impression confirmed successfully"}, nil
+}
+
+func (s *ServiceServer) WatchEvents(req *pb.WatchEventsRequest, stream pb.BannerService_WatchEventsServer) error {
+	if s.hub == nil {
+		return status.Errorf(codes.Unimplemented, "event stream is disabled")
+	}
+
+	filter := EventFilter{TypeEvents: req.GetTypeEvents()}
+	for _, id := range req.GetSlotIds() {
+		filter.SlotIDs = append(filter.SlotIDs, int(id))
+	}
+	for _, id := range req.GetBannerIds() {
+		filter.BannerIDs = append(filter.BannerIDs, int(id))
+	}
+
+	sub, cancel := s.hub.Subscribe(filter)
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case notification, ok := <-sub.Events():
+			if !ok {
+				return status.Errorf(codes.Unavailable, "server is shutting down")
+			}
+			if err := stream.Send(publisher.NewEvent(notification)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *ServiceServer) sendNotification(ctx context.Context, notification storage.Notification) error {
+	if s.hub != nil {
+		s.hub.Broadcast(notification)
+	}
+
+	// The callers log the error. Success is logged at debug level, it happens on every call.
+	if err := s.publisher.Publish(ctx, notification); err != nil {
 		return err
 	}
 
-	s.logger.Info(
-		"Sent a notification to queue RabbitMQ: %s %s",
-		string(notificationJSON),
+	s.logger.DebugContext(
+		ctx,
+		"Sent a notification to the event publisher: %+v %s",
+		notification,
 		time.Now().Format("2006-01-02 15:04"),
 	)
 	return nil
@@ -163,7 +263,7 @@ func (s *ServiceServer) sendNotification(notification storage.Notification) erro
 
 func createClickNotification(click *storage.Click) storage.Notification {
 	notification := storage.Notification{
-		TypeEvent:   "click",
+		TypeEvent:   storage.EventClick,
 		SlotID:      click.SlotID,
 		BannerID:    click.BannerID,
 		UsergroupID: click.UserGroupID,
@@ -173,29 +273,27 @@ func createClickNotification(click *storage.Click) storage.Notification {
 }
 
 func createImpressNotification(impress *storage.Impress) storage.Notification {
+	dateTime := impress.CreatedAt
+	if impress.ViewedAt != nil {
+		dateTime = *impress.ViewedAt
+	}
 	notification := storage.Notification{
-		TypeEvent:   "impress",
+		TypeEvent:   storage.EventImpress,
 		SlotID:      impress.SlotID,
 		BannerID:    impress.BannerID,
 		UsergroupID: impress.UserGroupID,
-		DateTime:    impress.CreatedAt,
+		DateTime:    dateTime,
 	}
 	return notification
 }
 
-func serializeNotification(notification storage.Notification) ([]byte, error) {
-	notificationJSON, err := json.Marshal(notification)
-	if err != nil {
-		return nil, err
-	}
-	return notificationJSON, nil
-}
-
-func publishNotificationToRMQ(notificationJSON []byte, rmq *rmq.Rmq) error {
-	msg := amqp.Publishing{
-		ContentType: "application/json",
-		Body:        notificationJSON,
+func createServeNotification(impress *storage.Impress) storage.Notification {
+	notification := storage.Notification{
+		TypeEvent:   storage.EventServe,
+		SlotID:      impress.SlotID,
+		BannerID:    impress.BannerID,
+		UsergroupID: impress.UserGroupID,
+		DateTime:    impress.CreatedAt,
 	}
-
-	return rmq.Publish(msg)
+	return notification
 }
@@ -0,0 +1,128 @@
+package internalgrpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ValidationInterceptor rejects requests with invalid fields before they reach the handlers.
+// The error is codes.InvalidArgument with an errdetails.BadRequest listing every invalid field.
+type ValidationInterceptor struct{}
+
+func NewValidationInterceptor() *ValidationInterceptor {
+	return &ValidationInterceptor{}
+}
+
+func (v *ValidationInterceptor) UnaryServerInterceptor(
+	ctx context.Context, req interface{},
+	_ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (v *ValidationInterceptor) StreamServerInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	_ *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &validatingStream{ServerStream: ss})
+}
+
+// validatingStream validates the messages received from the client.
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validateRequest(m)
+}
+
+type violations []*errdetails.BadRequest_FieldViolation
+
+// positiveID checks an ID field, named as in the proto definition.
+func (v *violations) positiveID(field string, value int32) {
+	if value <= 0 {
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf("must be positive, got %d", value),
+		})
+	}
+}
+
+func (v *violations) eventType(field, value string) {
+	switch value {
+	case storage.EventClick, storage.EventImpress, storage.EventServe:
+	default:
+		*v = append(*v, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: fmt.Sprintf("unknown event type %q", value),
+		})
+	}
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("invalid %s: %s", v[0].GetField(), v[0].GetDescription()))
+	if len(v) > 1 {
+		st = status.New(codes.InvalidArgument, fmt.Sprintf("%d invalid fields", len(v)))
+	}
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// validateRequest checks the fields of BannerService requests, other messages are not checked.
+func validateRequest(req interface{}) error {
+	var v violations
+	switch r := req.(type) {
+	case *pb.AddBannerRequest:
+		v.positiveID("banner_id", r.GetBannerId())
+		v.positiveID("slot_id", r.GetSlotId())
+	case *pb.RemoveBannerRequest:
+		v.positiveID("banner_id", r.GetBannerId())
+		v.positiveID("slot_id", r.GetSlotId())
+	case *pb.ClickBannerRequest:
+		v.positiveID("banner_id", r.GetBannerId())
+		v.positiveID("slot_id", r.GetSlotId())
+		v.positiveID("usergroup_id", r.GetUsergroupId())
+		if r.GetImpressionId() != 0 {
+			v.positiveID("impression_id", r.GetImpressionId())
+		}
+	case *pb.PickBannerRequest:
+		v.positiveID("slot_id", r.GetSlotId())
+		v.positiveID("usergroup_id", r.GetUsergroupId())
+	case *pb.ConfirmImpressionRequest:
+		v.positiveID("impression_id", r.GetImpressionId())
+	case *pb.WatchEventsRequest:
+		for i, id := range r.GetSlotIds() {
+			v.positiveID(fmt.Sprintf("slot_ids[%d]", i), id)
+		}
+		for i, id := range r.GetBannerIds() {
+			v.positiveID(fmt.Sprintf("banner_ids[%d]", i), id)
+		}
+		for i, typeEvent := range r.GetTypeEvents() {
+			v.eventType(fmt.Sprintf("type_events[%d]", i), typeEvent)
+		}
+	}
+	return v.err()
+}
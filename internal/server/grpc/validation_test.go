@@ -0,0 +1,39 @@
+package internalgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidation(t *testing.T) {
+	v := NewValidationInterceptor()
+	call := func(req interface{}) error {
+		_, err := v.UnaryServerInterceptor(context.Background(), req, &grpc.UnaryServerInfo{},
+			func(context.Context, interface{}) (interface{}, error) { return nil, nil })
+		return err
+	}
+
+	require.NoError(t, call(&pb.ClickBannerRequest{BannerId: 1, SlotId: 2, UsergroupId: 3}))
+	require.NoError(t, call(&pb.PickBannerRequest{SlotId: 2, UsergroupId: 3}))
+
+	err := call(&pb.RemoveBannerRequest{BannerId: 1, SlotId: -2})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, "invalid slot_id: must be positive, got -2", status.Convert(err).Message())
+
+	err = call(&pb.PickBannerRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	badRequest, ok := details[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.GetFieldViolations(), 2)
+	require.Equal(t, "slot_id", badRequest.GetFieldViolations()[0].GetField())
+	require.Equal(t, "usergroup_id", badRequest.GetFieldViolations()[1].GetField())
+}
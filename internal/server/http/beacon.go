@@ -0,0 +1,61 @@
+package internalhttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"google.golang.org/grpc/status"
+)
+
+const beaconPrefix = "/beacon/"
+
+// pixel is a transparent 1x1 GIF.
+var pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// handleBeacon serves the impression pixel GET /beacon/{token}: it confirms the served impression
+// encoded in the token the same way as ConfirmImpression. The token is issued by PickBanner in viewability mode.
+// HEAD only checks the token, link checkers and prefetchers must not mark impressions as viewed.
+func (s *Server) handleBeacon(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.clickTokens == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	impressionID, err := s.clickTokens.VerifyImpression(strings.TrimPrefix(r.URL.Path, beaconPrefix))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if _, err := s.client.ConfirmImpression(s.trackingContext(r), &pb.ConfirmImpressionRequest{
+			ImpressionId: impressionID,
+		}); err != nil {
+			st := status.Convert(err)
+			http.Error(w, st.Message(), HTTPStatusFromCode(st.Code()))
+			return
+		}
+	}
+
+	// Every view must reach the server, so the pixel must not be cached.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Content-Length", strconv.Itoa(len(pixel)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		if _, err := w.Write(pixel); err != nil {
+			s.logger.ErrorContext(r.Context(), "Failed to write beacon pixel: %v", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package internalhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/clicktoken"
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type beaconClient struct {
+	pb.BannerServiceClient
+	confirmed map[int32]bool
+}
+
+func (c *beaconClient) ConfirmImpression(
+	_ context.Context,
+	req *pb.ConfirmImpressionRequest,
+	_ ...grpc.CallOption,
+) (*pb.ConfirmImpressionResponse, error) {
+	if c.confirmed[req.GetImpressionId()] {
+		return nil, status.Error(codes.NotFound, "impression does not exist or is already viewed")
+	}
+	c.confirmed[req.GetImpressionId()] = true
+	return &pb.ConfirmImpressionResponse{Message: "Impression confirmed successfully"}, nil
+}
+
+func TestBeacon(t *testing.T) {
+	client := &beaconClient{confirmed: map[int32]bool{}}
+	signer := clicktoken.NewSigner("secret", time.Hour)
+	s := NewServer(client, logger.New("error", io.Discard), "localhost", 0)
+	s.SetClickTokenSigner(signer)
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, []byte) {
+		resp, err := http.Get(srv.URL + path) //nolint:noctx
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	// HEAD checks the token without confirming the impression.
+	head, err := http.Head(srv.URL + "/beacon/" + signer.SignImpression(7)) //nolint:noctx
+	require.NoError(t, err)
+	head.Body.Close()
+	require.Equal(t, http.StatusOK, head.StatusCode)
+	require.Equal(t, "image/gif", head.Header.Get("Content-Type"))
+	require.Empty(t, client.confirmed)
+
+	head, err = http.Head(srv.URL + "/beacon/i.7.forged") //nolint:noctx
+	require.NoError(t, err)
+	head.Body.Close()
+	require.Equal(t, http.StatusBadRequest, head.StatusCode)
+
+	resp, body := get("/beacon/" + signer.SignImpression(7))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "image/gif", resp.Header.Get("Content-Type"))
+	require.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	require.Equal(t, pixel, body)
+	require.True(t, client.confirmed[7])
+
+	resp, _ = get("/beacon/" + signer.SignImpression(7))
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = get("/beacon/" + signer.Sign(clicktoken.Click{BannerID: 1, SlotID: 2, UserGroupID: 3}))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, client.confirmed, 1)
+}
@@ -0,0 +1,88 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cronnoss/banners-rotation/internal/clicktoken"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const clickPrefix = "/click/"
+
+// SetClickTokenSigner enables the click-redirect endpoint GET /click/{token}. HEAD answers
+// the same redirect without recording the click, link checkers and prefetchers send it.
+func (s *Server) SetClickTokenSigner(signer *clicktoken.Signer) {
+	s.clickTokens = signer
+}
+
+// SetTrackingAPIKey sets the API key sent by the click and beacon endpoints. Browsers following
+// tracking links have no credentials of their own, the signed token is what they present instead.
+func (s *Server) SetTrackingAPIKey(key string) {
+	s.trackingKey = key
+}
+
+// trackingContext is the outgoing context of the click and beacon endpoints.
+func (s *Server) trackingContext(r *http.Request) context.Context {
+	ctx := outgoingContext(r)
+	if s.trackingKey == "" {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set("x-api-key", s.trackingKey)
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// handleClick records the click encoded in the token the same way as ClickBanner
+// and redirects the browser to the banner's target URL. Only GET records the click.
+func (s *Server) handleClick(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.clickTokens == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	click, err := s.clickTokens.Verify(strings.TrimPrefix(r.URL.Path, clickPrefix))
+	if errors.Is(err, clicktoken.ErrExpiredToken) {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	if err == nil && click.ImpressionID <= 0 {
+		// Only clicks of an impression are recorded once, so the link cannot be replayed.
+		err = clicktoken.ErrInvalidToken
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.client.ClickBanner(s.trackingContext(r), &pb.ClickBannerRequest{
+		BannerId:     click.BannerID,
+		SlotId:       click.SlotID,
+		UsergroupId:  click.UserGroupID,
+		ImpressionId: click.ImpressionID,
+		Preview:      r.Method == http.MethodHead,
+	})
+	if err != nil {
+		st := status.Convert(err)
+		http.Error(w, st.Message(), HTTPStatusFromCode(st.Code()))
+		return
+	}
+
+	// Every click must reach the server, so the redirect must not be cached.
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.GetTargetUrl() == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	http.Redirect(w, r, resp.GetTargetUrl(), http.StatusFound)
+}
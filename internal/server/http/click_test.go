@@ -0,0 +1,116 @@
+package internalhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/clicktoken"
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type clickClient struct {
+	pb.BannerServiceClient
+	clicks  []*pb.ClickBannerRequest
+	clicked map[int32]bool // Impressions already clicked.
+}
+
+func (c *clickClient) ClickBanner(
+	_ context.Context,
+	req *pb.ClickBannerRequest,
+	_ ...grpc.CallOption,
+) (*pb.ClickBannerResponse, error) {
+	if req.GetUsergroupId() == 70 {
+		return nil, status.Error(codes.NotFound, "specified userGroup does not exist")
+	}
+	if req.GetPreview() {
+		return &pb.ClickBannerResponse{Message: "Banner click previewed", TargetUrl: "https://example.com/"}, nil
+	}
+	if c.clicked[req.GetImpressionId()] {
+		return nil, status.Error(codes.AlreadyExists, "impression is unknown or already clicked")
+	}
+	c.clicked[req.GetImpressionId()] = true
+	c.clicks = append(c.clicks, req)
+	if req.GetBannerId() == 2 {
+		return &pb.ClickBannerResponse{Message: "Banner clicked successfully"}, nil
+	}
+	return &pb.ClickBannerResponse{Message: "Banner clicked successfully", TargetUrl: "https://example.com/"}, nil
+}
+
+func TestClickRedirect(t *testing.T) {
+	client := &clickClient{clicked: map[int32]bool{}}
+	signer := clicktoken.NewSigner("secret", time.Hour)
+	s := NewServer(client, logger.New("error", io.Discard), "localhost", 0)
+	s.SetClickTokenSigner(signer)
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	get := func(path string) *http.Response {
+		resp, err := noRedirect.Get(srv.URL + path) //nolint:noctx
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// HEAD redirects without recording the click.
+	token := signer.Sign(clicktoken.Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 4})
+	resp, err := noRedirect.Head(srv.URL + "/click/" + token) //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "https://example.com/", resp.Header.Get("Location"))
+	require.Empty(t, client.clicks)
+
+	resp = get("/click/" + token)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Equal(t, "https://example.com/", resp.Header.Get("Location"))
+	require.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	require.Len(t, client.clicks, 1)
+	require.Equal(t, int32(1), client.clicks[0].GetBannerId())
+	require.Equal(t, int32(2), client.clicks[0].GetSlotId())
+	require.Equal(t, int32(3), client.clicks[0].GetUsergroupId())
+	require.Equal(t, int32(4), client.clicks[0].GetImpressionId())
+
+	// The link of an impression is clicked once.
+	resp = get("/click/" + token)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = get("/click/" + signer.Sign(clicktoken.Click{BannerID: 2, SlotID: 2, UserGroupID: 3, ImpressionID: 5}))
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = get("/click/" + signer.Sign(clicktoken.Click{BannerID: 1, SlotID: 2, UserGroupID: 70, ImpressionID: 6}))
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = get("/click/1.2.3.forged")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = get("/click/" + signer.Sign(clicktoken.Click{BannerID: 1, SlotID: 2, UserGroupID: 3}))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	expired := clicktoken.NewSigner("secret", -time.Minute)
+	resp = get("/click/" + expired.Sign(clicktoken.Click{BannerID: 1, SlotID: 2, UserGroupID: 3, ImpressionID: 7}))
+	require.Equal(t, http.StatusGone, resp.StatusCode)
+	require.Len(t, client.clicks, 2)
+}
+
+func TestClickRedirectDisabled(t *testing.T) {
+	s := NewServer(&clickClient{}, logger.New("error", io.Discard), "localhost", 0)
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/click/1.2.3.sig") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
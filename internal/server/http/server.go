@@ -0,0 +1,232 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/clicktoken"
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/requestid"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	apiPrefix         = "/v1/"
+	maxBodySize       = 1 << 20
+	readHeaderTimeout = 5 * time.Second
+)
+
+// forwardedHeaders are passed to the gRPC server as metadata. The W3C trace context headers
+// let the gRPC spans continue the trace of the HTTP client.
+var forwardedHeaders = []string{"authorization", "x-api-key", "x-request-id", "traceparent", "tracestate"}
+
+// Server is an HTTP/JSON gateway to BannerService. Every call goes through the gRPC client,
+// so the gRPC interceptors, validation and error codes apply to HTTP requests as well.
+type Server struct {
+	client      pb.BannerServiceClient
+	logger      *logger.Logger
+	server      *http.Server
+	rpcs        map[string]rpc
+	clickTokens *clicktoken.Signer
+	trackingKey string
+}
+
+// rpc decodes a JSON request body and calls the corresponding gRPC method.
+type rpc func(ctx context.Context, body []byte) (proto.Message, error)
+
+func NewServer(client pb.BannerServiceClient, logg *logger.Logger, host string, port int) *Server {
+	s := &Server{
+		client: client,
+		logger: logg,
+	}
+
+	// New RPCs of BannerService are exposed by adding them here.
+	s.rpcs = map[string]rpc{
+		"AddBanner":    unary(func() *pb.AddBannerRequest { return &pb.AddBannerRequest{} }, client.AddBanner),
+		"RemoveBanner": unary(func() *pb.RemoveBannerRequest { return &pb.RemoveBannerRequest{} }, client.RemoveBanner),
+		"ClickBanner":  unary(func() *pb.ClickBannerRequest { return &pb.ClickBannerRequest{} }, client.ClickBanner),
+		"PickBanner":   unary(func() *pb.PickBannerRequest { return &pb.PickBannerRequest{} }, client.PickBanner),
+		"ConfirmImpression": unary(
+			func() *pb.ConfirmImpressionRequest { return &pb.ConfirmImpressionRequest{} },
+			client.ConfirmImpression,
+		),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPrefix, s.handleRPC)
+	mux.HandleFunc(clickPrefix, s.handleClick)
+	mux.HandleFunc(beaconPrefix, s.handleBeacon)
+
+	s.server = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", host, port),
+		Handler:           withRequestID(mux),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	return s
+}
+
+func (s *Server) Start() error {
+	s.logger.Info("Starting HTTP server on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// Handler returns the HTTP handler of the gateway.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// handleRPC serves POST /v1/{Method} with the JSON form of the request message as the body.
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		s.writeError(w, status.Error(codes.Unimplemented, "method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	call, ok := s.rpcs[strings.TrimPrefix(r.URL.Path, apiPrefix)]
+	if !ok {
+		s.writeError(w, status.Errorf(codes.NotFound, "unknown method %s", r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		s.writeError(w, status.Errorf(codes.InvalidArgument, "failed to read body: %v", err), 0)
+		return
+	}
+
+	resp, err := call(outgoingContext(r), body)
+	if err != nil {
+		s.writeError(w, err, 0)
+		return
+	}
+
+	s.writeMessage(w, http.StatusOK, resp)
+}
+
+func unary[Req proto.Message, Resp proto.Message](
+	newRequest func() Req,
+	call func(ctx context.Context, req Req, opts ...grpc.CallOption) (Resp, error),
+) rpc {
+	return func(ctx context.Context, body []byte) (proto.Message, error) {
+		req := newRequest()
+		if len(body) > 0 {
+			if err := protojson.Unmarshal(body, req); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+			}
+		}
+		return call(ctx, req)
+	}
+}
+
+// withRequestID makes sure that every request has a valid X-Request-Id, generated if needed.
+// It is forwarded to the gRPC server and returned in the response.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+			r.Header.Set(requestid.Header, id)
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// outgoingContext turns the forwarded HTTP headers and the client address into gRPC metadata.
+func outgoingContext(r *http.Request) context.Context {
+	md := metadata.MD{}
+	for _, h := range forwardedHeaders {
+		if v := r.Header.Get(h); v != "" {
+			md.Set(h, v)
+		}
+	}
+
+	forwardedFor := r.Header.Get("X-Forwarded-For")
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if forwardedFor != "" {
+			forwardedFor += ", "
+		}
+		forwardedFor += host
+	}
+	if forwardedFor != "" {
+		md.Set("x-forwarded-for", forwardedFor)
+	}
+
+	return metadata.NewOutgoingContext(r.Context(), md)
+}
+
+func (s *Server) writeMessage(w http.ResponseWriter, code int, msg proto.Message) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		s.logger.Error("Failed to marshal HTTP response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err := w.Write(data); err != nil {
+		s.logger.Error("Failed to write HTTP response: %v", err)
+	}
+}
+
+// writeError writes the gRPC status of err. The HTTP code is derived from the gRPC code unless httpCode is set.
+func (s *Server) writeError(w http.ResponseWriter, err error, httpCode int) {
+	st := status.Convert(err)
+	if httpCode == 0 {
+		httpCode = HTTPStatusFromCode(st.Code())
+	}
+	s.writeMessage(w, httpCode, st.Proto())
+}
+
+// HTTPStatusFromCode maps a gRPC code to the HTTP status code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request.
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
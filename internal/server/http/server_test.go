@@ -0,0 +1,133 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cronnoss/banners-rotation/internal/logger"
+	"github.com/cronnoss/banners-rotation/internal/server/pb"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeClient struct {
+	pb.BannerServiceClient
+	md metadata.MD
+}
+
+func (f *fakeClient) AddBanner(
+	ctx context.Context,
+	req *pb.AddBannerRequest,
+	_ ...grpc.CallOption,
+) (*pb.AddBannerResponse, error) {
+	f.md, _ = metadata.FromOutgoingContext(ctx)
+	if req.GetBannerId() == 60 {
+		return nil, status.Error(codes.NotFound, "specified banner does not exist")
+	}
+	return &pb.AddBannerResponse{Message: "Banner added successfully"}, nil
+}
+
+func (f *fakeClient) PickBanner(
+	_ context.Context,
+	req *pb.PickBannerRequest,
+	_ ...grpc.CallOption,
+) (*pb.PickBannerResponse, error) {
+	return &pb.PickBannerResponse{BannerId: req.GetSlotId() + 1, Message: "Banner picked successfully"}, nil
+}
+
+func newTestServer(client pb.BannerServiceClient) *httptest.Server {
+	s := NewServer(client, logger.New("error", io.Discard), "localhost", 0)
+	return httptest.NewServer(s.Handler())
+}
+
+func post(t *testing.T, url, body string, header http.Header) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&decoded))
+	return resp.StatusCode, decoded
+}
+
+func TestGatewayCallsRPC(t *testing.T) {
+	client := &fakeClient{}
+	srv := newTestServer(client)
+	defer srv.Close()
+
+	code, body := post(t, srv.URL+"/v1/AddBanner", `{"banner_id": 1, "slot_id": 2}`,
+		http.Header{"X-Api-Key": {"secret"}, "X-Forwarded-For": {"10.0.0.1"}})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "Banner added successfully", body["message"])
+	require.Equal(t, []string{"secret"}, client.md.Get("x-api-key"))
+	require.Equal(t, []string{"10.0.0.1, 127.0.0.1"}, client.md.Get("x-forwarded-for"))
+
+	code, body = post(t, srv.URL+"/v1/PickBanner", `{"slotId": 2, "usergroup_id": 1}`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, float64(3), body["banner_id"])
+}
+
+func TestGatewayRequestID(t *testing.T) {
+	client := &fakeClient{}
+	s := NewServer(client, logger.New("error", io.Discard), "localhost", 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/AddBanner", strings.NewReader(`{"banner_id": 1, "slot_id": 2}`))
+	req.Header.Set("X-Request-Id", "req-1")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	require.Equal(t, "req-1", rec.Header().Get("X-Request-Id"))
+	require.Equal(t, []string{"req-1"}, client.md.Get("x-request-id"))
+
+	// Without an ID one is generated and forwarded.
+	req = httptest.NewRequest(http.MethodPost, "/v1/AddBanner", strings.NewReader(`{"banner_id": 1, "slot_id": 2}`))
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	id := rec.Header().Get("X-Request-Id")
+	require.NotEmpty(t, id)
+	require.Equal(t, []string{id}, client.md.Get("x-request-id"))
+}
+
+func TestGatewayErrors(t *testing.T) {
+	srv := newTestServer(&fakeClient{})
+	defer srv.Close()
+
+	code, body := post(t, srv.URL+"/v1/AddBanner", `{"banner_id": 60, "slot_id": 2}`, nil)
+	require.Equal(t, http.StatusNotFound, code)
+	require.Equal(t, float64(codes.NotFound), body["code"])
+	require.Equal(t, "specified banner does not exist", body["message"])
+
+	code, body = post(t, srv.URL+"/v1/AddBanner", `{"banner_id": "one"}`, nil)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, float64(codes.InvalidArgument), body["code"])
+
+	code, _ = post(t, srv.URL+"/v1/DropTables", `{}`, nil)
+	require.Equal(t, http.StatusNotFound, code)
+
+	resp, err := http.Get(srv.URL + "/v1/AddBanner") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	require.Equal(t, http.StatusOK, HTTPStatusFromCode(codes.OK))
+	require.Equal(t, http.StatusBadRequest, HTTPStatusFromCode(codes.FailedPrecondition))
+	require.Equal(t, http.StatusConflict, HTTPStatusFromCode(codes.AlreadyExists))
+	require.Equal(t, http.StatusTooManyRequests, HTTPStatusFromCode(codes.ResourceExhausted))
+	require.Equal(t, http.StatusInternalServerError, HTTPStatusFromCode(codes.Internal))
+}
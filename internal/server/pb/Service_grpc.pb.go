@@ -19,10 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	BannerService_AddBanner_FullMethodName    = "/banner.BannerService/AddBanner"
-	BannerService_RemoveBanner_FullMethodName = "/banner.BannerService/RemoveBanner"
-	BannerService_ClickBanner_FullMethodName  = "/banner.BannerService/ClickBanner"
-	BannerService_PickBanner_FullMethodName   = "/banner.BannerService/PickBanner"
+	BannerService_AddBanner_FullMethodName         = "/banner.BannerService/AddBanner"
+	BannerService_RemoveBanner_FullMethodName      = "/banner.BannerService/RemoveBanner"
+	BannerService_ClickBanner_FullMethodName       = "/banner.BannerService/ClickBanner"
+	BannerService_PickBanner_FullMethodName        = "/banner.BannerService/PickBanner"
+	BannerService_ConfirmImpression_FullMethodName = "/banner.BannerService/ConfirmImpression"
+	BannerService_WatchEvents_FullMethodName       = "/banner.BannerService/WatchEvents"
 )
 
 // BannerServiceClient is the client API for BannerService service.
@@ -33,6 +35,10 @@ type BannerServiceClient interface {
 	RemoveBanner(ctx context.Context, in *RemoveBannerRequest, opts ...grpc.CallOption) (*RemoveBannerResponse, error)
 	ClickBanner(ctx context.Context, in *ClickBannerRequest, opts ...grpc.CallOption) (*ClickBannerResponse, error)
 	PickBanner(ctx context.Context, in *PickBannerRequest, opts ...grpc.CallOption) (*PickBannerResponse, error)
+	// ConfirmImpression records that a banner served by PickBanner was actually viewed.
+	ConfirmImpression(ctx context.Context, in *ConfirmImpressionRequest, opts ...grpc.CallOption) (*ConfirmImpressionResponse, error)
+	// WatchEvents streams the click and impression events as they happen.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (BannerService_WatchEventsClient, error)
 }
 
 type bannerServiceClient struct {
@@ -79,6 +85,47 @@ func (c *bannerServiceClient) PickBanner(ctx context.Context, in *PickBannerRequ
 	return out, nil
 }
 
+func (c *bannerServiceClient) ConfirmImpression(ctx context.Context, in *ConfirmImpressionRequest, opts ...grpc.CallOption) (*ConfirmImpressionResponse, error) {
+	out := new(ConfirmImpressionResponse)
+	err := c.cc.Invoke(ctx, BannerService_ConfirmImpression_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bannerServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (BannerService_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BannerService_ServiceDesc.Streams[0], BannerService_WatchEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bannerServiceWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BannerService_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type bannerServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *bannerServiceWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // BannerServiceServer is the server API for BannerService service.
 // All implementations must embed UnimplementedBannerServiceServer
 // for forward compatibility
@@ -87,6 +134,10 @@ type BannerServiceServer interface {
 	RemoveBanner(context.Context, *RemoveBannerRequest) (*RemoveBannerResponse, error)
 	ClickBanner(context.Context, *ClickBannerRequest) (*ClickBannerResponse, error)
 	PickBanner(context.Context, *PickBannerRequest) (*PickBannerResponse, error)
+	// ConfirmImpression records that a banner served by PickBanner was actually viewed.
+	ConfirmImpression(context.Context, *ConfirmImpressionRequest) (*ConfirmImpressionResponse, error)
+	// WatchEvents streams the click and impression events as they happen.
+	WatchEvents(*WatchEventsRequest, BannerService_WatchEventsServer) error
 	mustEmbedUnimplementedBannerServiceServer()
 }
 
@@ -106,6 +157,12 @@ func (UnimplementedBannerServiceServer) ClickBanner(context.Context, *ClickBanne
 func (UnimplementedBannerServiceServer) PickBanner(context.Context, *PickBannerRequest) (*PickBannerResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PickBanner not implemented")
 }
+func (UnimplementedBannerServiceServer) ConfirmImpression(context.Context, *ConfirmImpressionRequest) (*ConfirmImpressionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmImpression not implemented")
+}
+func (UnimplementedBannerServiceServer) WatchEvents(*WatchEventsRequest, BannerService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
 func (UnimplementedBannerServiceServer) mustEmbedUnimplementedBannerServiceServer() {}
 
 // UnsafeBannerServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -191,6 +248,45 @@ func _BannerService_PickBanner_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BannerService_ConfirmImpression_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmImpressionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BannerServiceServer).ConfirmImpression(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BannerService_ConfirmImpression_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BannerServiceServer).ConfirmImpression(ctx, req.(*ConfirmImpressionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BannerService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BannerServiceServer).WatchEvents(m, &bannerServiceWatchEventsServer{stream})
+}
+
+type BannerService_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type bannerServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *bannerServiceWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // BannerService_ServiceDesc is the grpc.ServiceDesc for BannerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -214,7 +310,17 @@ var BannerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PickBanner",
 			Handler:    _BannerService_PickBanner_Handler,
 		},
+		{
+			MethodName: "ConfirmImpression",
+			Handler:    _BannerService_ConfirmImpression_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _BannerService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "Service.proto",
 }
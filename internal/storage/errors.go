@@ -0,0 +1,10 @@
+package storage
+
+import "errors"
+
+// Errors returned by the storage implementations, wrapped with the details.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("already exists")
+	ErrNoBanners = errors.New("no banners for a given slot")
+)
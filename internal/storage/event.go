@@ -2,6 +2,20 @@ package storage
 
 import "time"
 
+// Event types of Notification.
+const (
+	EventClick   = "click"
+	EventImpress = "impress" // The banner was viewed and counted as an impression.
+	EventServe   = "serve"   // The banner was served in viewability mode and is not viewed yet.
+)
+
+// Impression statuses. In viewability mode PickBanner records served impressions,
+// they become viewed once the impression beacon is requested.
+const (
+	ImpressStatusServed = "served"
+	ImpressStatusViewed = "viewed"
+)
+
 type Click struct {
 	ID          int       `json:"id"`
 	SlotID      int       `json:"slot_id"`      //nolint:tagliatelle
@@ -11,11 +25,13 @@ type Click struct {
 }
 
 type Impress struct {
-	ID          int       `json:"id"`
-	SlotID      int       `json:"slot_id"`      //nolint:tagliatelle
-	BannerID    int       `json:"banner_id"`    //nolint:tagliatelle
-	UserGroupID int       `json:"usergroup_id"` //nolint:tagliatelle
-	CreatedAt   time.Time `json:"created_at"`   //nolint:tagliatelle
+	ID          int        `json:"id"`
+	SlotID      int        `json:"slot_id"`      //nolint:tagliatelle
+	BannerID    int        `json:"banner_id"`    //nolint:tagliatelle
+	UserGroupID int        `json:"usergroup_id"` //nolint:tagliatelle
+	CreatedAt   time.Time  `json:"created_at"`   //nolint:tagliatelle
+	Status      string     `json:"status"`
+	ViewedAt    *time.Time `json:"viewed_at,omitempty"` //nolint:tagliatelle
 }
 
 type Notification struct {
@@ -0,0 +1,8 @@
+package storage
+
+// Existence tells which of the banner, slot and user group exist.
+type Existence struct {
+	Banner    bool
+	Slot      bool
+	UserGroup bool
+}
@@ -0,0 +1,35 @@
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/jackc/pgx"
+)
+
+// PostgreSQL error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// mapError converts the driver errors to the storage sentinel errors.
+func mapError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", storage.ErrNotFound, err)
+	}
+
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case foreignKeyViolation:
+			return fmt.Errorf("%w: %s", storage.ErrNotFound, pgErr.Detail)
+		case uniqueViolation:
+			return fmt.Errorf("%w: %s", storage.ErrConflict, pgErr.Detail)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cronnoss/banners-rotation/internal/metrics"
+	"github.com/cronnoss/banners-rotation/internal/storage"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cronnoss/banners-rotation/internal/storage/sql")
+
+// instrument starts a span of the query. The returned function ends it and records the latency.
+// Errors are counted unless they are expected outcomes such as a missing row, which the callers
+// report to the clients.
+func instrument(ctx context.Context, query string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "sql."+query,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(query)),
+	)
+
+	return ctx, func(err *error) {
+		defer span.End()
+		metrics.DBQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+		if *err == nil ||
+			errors.Is(*err, storage.ErrNotFound) ||
+			errors.Is(*err, storage.ErrConflict) ||
+			errors.Is(*err, storage.ErrNoBanners) {
+			return
+		}
+		metrics.DBQueryErrors.WithLabelValues(query).Inc()
+		span.RecordError(*err)
+		span.SetStatus(otelcodes.Error, (*err).Error())
+	}
+}
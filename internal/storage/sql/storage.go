@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 
@@ -13,16 +14,21 @@ import (
 	"github.com/pressly/goose/v3"
 )
 
-var errNoBannersForGivenSlot = errors.New("no banners for a given slot")
-
 type Storage struct {
-	db *sqlx.DB
+	db          *sqlx.DB
+	viewability bool
 }
 
 func NewStorage(db *sqlx.DB) *Storage {
 	return &Storage{db: db}
 }
 
+// SetViewability switches viewability mode: picked banners are recorded as served and only impressions
+// confirmed by ConfirmImpression are counted by the bandit.
+func (s *Storage) SetViewability(enabled bool) {
+	s.viewability = enabled
+}
+
 func (s *Storage) Migrate(ctx context.Context, migrate string) (err error) {
 	_ = ctx
 	if err := goose.SetDialect("pgx"); err != nil {
@@ -48,65 +54,176 @@ func (s *Storage) Connect(ctx context.Context, dbPort int, dbHost, dbUser, dbPas
 	return s.db.PingContext(ctx)
 }
 
+func (s *Storage) Ping(ctx context.Context) (err error) {
+	ctx, done := instrument(ctx, "ping")
+	defer done(&err)
+	return s.db.PingContext(ctx)
+}
+
 func (s *Storage) Close(ctx context.Context) error {
 	_ = ctx
 	return s.db.Close()
 }
 
-func (s *Storage) AddBanner(ctx context.Context, bannerID, slotID int) error {
+func (s *Storage) AddBanner(ctx context.Context, bannerID, slotID int) (err error) {
+	ctx, done := instrument(ctx, "add_banner")
+	defer done(&err)
+
 	const query = `
 		INSERT INTO rotations (slot_id, banner_id, created_at)
 		VALUES ($1, $2, NOW());
 	`
-	_, err := s.db.ExecContext(ctx, query, slotID, bannerID)
+	_, err = s.db.ExecContext(ctx, query, slotID, bannerID)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 	return nil
 }
 
-func (s *Storage) RemoveBanner(ctx context.Context, bannerID, slotID int) error {
+func (s *Storage) RemoveBanner(ctx context.Context, bannerID, slotID int) (err error) {
+	ctx, done := instrument(ctx, "remove_banner")
+	defer done(&err)
+
 	const query = `DELETE FROM rotations WHERE slot_id = $1 and banner_id = $2;`
 
-	_, err := s.db.ExecContext(ctx, query, slotID, bannerID)
+	res, err := s.db.ExecContext(ctx, query, slotID, bannerID)
 	if err != nil {
 		return err
 	}
 
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: banner %d is not assigned to slot %d", storage.ErrNotFound, bannerID, slotID)
+	}
+
 	return nil
 }
 
-func (s *Storage) ClickBanner(ctx context.Context, bannerID, slotID, userGroupID int) (*storage.Click, error) {
+func (s *Storage) ClickBanner(
+	ctx context.Context,
+	bannerID, slotID, userGroupID int,
+) (_ *storage.Click, err error) {
+	ctx, done := instrument(ctx, "click_banner")
+	defer done(&err)
+
 	const query = `
 		INSERT INTO clicks (slot_id, banner_id, usergroup_id, created_at) 
 		VALUES ($1, $2, $3, NOW())
 		RETURNING id, slot_id, banner_id, usergroup_id, created_at;`
 
 	click := &storage.Click{}
-	err := s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID).
+	err = s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID).
 		Scan(&click.ID, &click.SlotID, &click.BannerID, &click.UserGroupID, &click.CreatedAt)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return click, nil
 }
 
+// ClickImpression records the click of an impression, once. It returns storage.ErrNotFound if the impression
+// does not exist or does not match the banner, slot and user group, storage.ErrConflict if it is already clicked.
+func (s *Storage) ClickImpression(
+	ctx context.Context,
+	impressionID, bannerID, slotID, userGroupID int,
+) (_ *storage.Click, err error) {
+	ctx, done := instrument(ctx, "click_impression")
+	defer done(&err)
+
+	const query = `
+		WITH impression AS (
+			UPDATE impressions
+			SET clicked_at = NOW()
+			WHERE id = $4 AND slot_id = $1 AND banner_id = $2 AND usergroup_id = $3 AND clicked_at IS NULL
+			RETURNING id
+		)
+		INSERT INTO clicks (slot_id, banner_id, usergroup_id, created_at)
+		SELECT $1, $2, $3, NOW() FROM impression
+		RETURNING id, slot_id, banner_id, usergroup_id, created_at;`
+
+	click := &storage.Click{}
+	err = s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID, impressionID).
+		Scan(&click.ID, &click.SlotID, &click.BannerID, &click.UserGroupID, &click.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.impressionClickError(ctx, impressionID, bannerID, slotID, userGroupID)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return click, nil
+}
+
+// impressionClickError tells why ClickImpression recorded nothing.
+func (s *Storage) impressionClickError(ctx context.Context, impressionID, bannerID, slotID, userGroupID int) error {
+	const query = `
+		SELECT clicked_at IS NOT NULL
+		FROM impressions
+		WHERE id = $4 AND slot_id = $1 AND banner_id = $2 AND usergroup_id = $3;`
+
+	var clicked bool
+	err := s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID, impressionID).Scan(&clicked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: impression %d of banner %d, slot %d and user group %d",
+			storage.ErrNotFound, impressionID, bannerID, slotID, userGroupID)
+	}
+	if err != nil {
+		return mapError(err)
+	}
+	return fmt.Errorf("%w: impression %d is already clicked", storage.ErrConflict, impressionID)
+}
+
 func (s *Storage) PickBanner(ctx context.Context, slotID, usergroupID int) (*storage.Impress, int, error) {
+	banners, err := s.bannerStatistics(ctx, slotID, usergroupID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(banners) == 0 {
+		return nil, 0, fmt.Errorf("%w: %d", storage.ErrNoBanners, slotID)
+	}
+
+	bannerID := multiarmedbandit.PickBanner(banners)
+
+	status := storage.ImpressStatusViewed
+	if s.viewability {
+		status = storage.ImpressStatusServed
+	}
+
+	impress, err := s.impressBanner(ctx, bannerID, slotID, usergroupID, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return impress, bannerID, nil
+}
+
+// bannerStatistics returns the impressions and clicks of the banners in the slot for the user group.
+func (s *Storage) bannerStatistics(
+	ctx context.Context,
+	slotID, usergroupID int,
+) (_ []multiarmedbandit.Banner, err error) {
+	ctx, done := instrument(ctx, "banner_statistics")
+	defer done(&err)
+
 	const query = `
 		SELECT
 			r.banner_id,
-			(SELECT COUNT(*) FROM impressions i WHERE i.banner_id = r.banner_id AND i.usergroup_id = $1) AS impressions,
+			(SELECT COUNT(*) FROM impressions i
+				WHERE i.banner_id = r.banner_id AND i.usergroup_id = $1 AND i.status = $3) AS impressions,
 			(SELECT COUNT(*) FROM clicks c WHERE c.banner_id = r.banner_id AND c.usergroup_id = $1) AS clicks
 		FROM rotations r
 		WHERE r.slot_id = $2;`
 
-	rows, err := s.db.QueryContext(ctx, query, usergroupID, slotID)
+	rows, err := s.db.QueryContext(ctx, query, usergroupID, slotID, storage.ImpressStatusViewed)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	if err = rows.Err(); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	defer rows.Close()
@@ -114,100 +231,148 @@ func (s *Storage) PickBanner(ctx context.Context, slotID, usergroupID int) (*sto
 	banners := make([]multiarmedbandit.Banner, 0)
 	for rows.Next() {
 		var bnr storage.BannerStatistics
-		if err := rows.Scan(&bnr.BannerID, &bnr.Impressions, &bnr.Clicks); err != nil {
-			return nil, 0, err
+		if err = rows.Scan(&bnr.BannerID, &bnr.Impressions, &bnr.Clicks); err != nil {
+			return nil, err
 		}
 		banners = append(banners, &bnr)
 	}
 
-	if len(banners) == 0 {
-		return nil, 0, errNoBannersForGivenSlot
-	}
+	return banners, nil
+}
 
-	bannerID := multiarmedbandit.PickBanner(banners)
+func (s *Storage) ImpressBanner(ctx context.Context, bannerID, slotID, userGroupID int) (*storage.Impress, error) {
+	return s.impressBanner(ctx, bannerID, slotID, userGroupID, storage.ImpressStatusViewed)
+}
+
+func (s *Storage) impressBanner(
+	ctx context.Context,
+	bannerID, slotID, userGroupID int,
+	status string,
+) (_ *storage.Impress, err error) {
+	ctx, done := instrument(ctx, "impress_banner")
+	defer done(&err)
+
+	const query = `
+		INSERT INTO impressions
+		(slot_id, banner_id, usergroup_id, created_at, status, viewed_at) VALUES
+		($1, $2, $3, NOW(), $4, CASE WHEN $4::VARCHAR = 'viewed' THEN NOW() END)
+		RETURNING id, slot_id, banner_id, usergroup_id, created_at, status, viewed_at;`
 
-	impress, err := s.ImpressBanner(ctx, bannerID, slotID, usergroupID)
+	impress := &storage.Impress{}
+	err = s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID, status).
+		Scan(&impress.ID, &impress.SlotID, &impress.BannerID, &impress.UserGroupID, &impress.CreatedAt,
+			&impress.Status, &impress.ViewedAt)
 	if err != nil {
-		return nil, 0, err
+		return nil, mapError(err)
 	}
 
-	return impress, bannerID, nil
+	return impress, nil
 }
 
-func (s *Storage) ImpressBanner(ctx context.Context, bannerID, slotID, userGroupID int) (*storage.Impress, error) {
+// ConfirmImpression marks a served impression as viewed. It returns storage.ErrNotFound
+// if the impression does not exist or is already viewed.
+func (s *Storage) ConfirmImpression(ctx context.Context, impressionID int) (_ *storage.Impress, err error) {
+	ctx, done := instrument(ctx, "confirm_impression")
+	defer done(&err)
+
 	const query = `
-		INSERT INTO impressions
-		(slot_id, banner_id, usergroup_id, created_at) VALUES
-		($1, $2, $3, NOW())
-		RETURNING id, slot_id, banner_id, usergroup_id, created_at;`
+		UPDATE impressions
+		SET status = $2, viewed_at = NOW()
+		WHERE id = $1 AND status = $3
+		RETURNING id, slot_id, banner_id, usergroup_id, created_at, status, viewed_at;`
 
 	impress := &storage.Impress{}
-	err := s.db.QueryRowContext(ctx, query, slotID, bannerID, userGroupID).
-		Scan(&impress.ID, &impress.SlotID, &impress.BannerID, &impress.UserGroupID, &impress.CreatedAt)
+	err = s.db.QueryRowContext(ctx, query, impressionID, storage.ImpressStatusViewed, storage.ImpressStatusServed).
+		Scan(&impress.ID, &impress.SlotID, &impress.BannerID, &impress.UserGroupID, &impress.CreatedAt,
+			&impress.Status, &impress.ViewedAt)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
-	return impress, err
+	return impress, nil
 }
 
-func (s *Storage) IsBannerAssignedToSlot(ctx context.Context, bannerID, slotID int) (bool, error) {
-	const query = `
-        SELECT COUNT(*)
-        FROM rotations
-        WHERE banner_id = $1 AND slot_id = $2;`
+func (s *Storage) BannerTargetURL(ctx context.Context, bannerID int) (_ string, err error) {
+	ctx, done := instrument(ctx, "banner_target_url")
+	defer done(&err)
 
-	var count int
-	err := s.db.QueryRowContext(ctx, query, bannerID, slotID).Scan(&count)
-	if err != nil {
-		return false, err
+	const query = `SELECT target_url FROM banners WHERE id = $1;`
+
+	var targetURL string
+	if err = s.db.QueryRowContext(ctx, query, bannerID).Scan(&targetURL); err != nil {
+		return "", mapError(err)
 	}
 
-	// Если count > 0, значит баннер уже присвоен слоту
-	return count > 0, nil
+	return targetURL, nil
 }
 
-func (s *Storage) BannerExists(ctx context.Context, bannerID int) bool {
-	const query = `
-      SELECT COUNT(*)
-      FROM banners
-      WHERE id = $1;`
+// APIKeyRole returns the role of the API key with the given hash. It returns storage.ErrNotFound
+// if the key does not exist or is revoked.
+func (s *Storage) APIKeyRole(ctx context.Context, keyHash string) (_ string, err error) {
+	ctx, done := instrument(ctx, "api_key_role")
+	defer done(&err)
+
+	const query = `SELECT role FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL;`
+
+	var role string
+	if err = s.db.QueryRowContext(ctx, query, keyHash).Scan(&role); err != nil {
+		return "", mapError(err)
+	}
+
+	return role, nil
+}
+
+// CountAPIKeys returns the number of API keys not revoked.
+func (s *Storage) CountAPIKeys(ctx context.Context) (_ int, err error) {
+	ctx, done := instrument(ctx, "count_api_keys")
+	defer done(&err)
+
+	const query = `SELECT COUNT(*) FROM api_keys WHERE revoked_at IS NULL;`
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, bannerID).Scan(&count)
-	if err != nil {
-		return false
+	if err = s.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, mapError(err)
 	}
 
-	return count > 0
+	return count, nil
 }
 
-func (s *Storage) SlotExists(ctx context.Context, slotID int) bool {
+func (s *Storage) IsBannerAssignedToSlot(ctx context.Context, bannerID, slotID int) (_ bool, err error) {
+	ctx, done := instrument(ctx, "is_banner_assigned_to_slot")
+	defer done(&err)
+
 	const query = `
-      SELECT COUNT(*)
-      FROM slots
-      WHERE id = $1;`
+        SELECT COUNT(*)
+        FROM rotations
+        WHERE banner_id = $1 AND slot_id = $2;`
 
 	var count int
-	err := s.db.QueryRowContext(ctx, query, slotID).Scan(&count)
+	err = s.db.QueryRowContext(ctx, query, bannerID, slotID).Scan(&count)
 	if err != nil {
-		return false
+		return false, err
 	}
 
-	return count > 0
+	// Если count > 0, значит баннер уже присвоен слоту
+	return count > 0, nil
 }
 
-func (s *Storage) UserGroupExists(ctx context.Context, userGroupID int) bool {
+// Exist checks the existence of the banner, the slot and the user group in a single query.
+func (s *Storage) Exist(ctx context.Context, bannerID, slotID, userGroupID int) (_ storage.Existence, err error) {
+	ctx, done := instrument(ctx, "exist")
+	defer done(&err)
+
 	const query = `
-      SELECT COUNT(*)
-      FROM usergroups
-      WHERE id = $1;`
+		SELECT
+			EXISTS(SELECT 1 FROM banners WHERE id = $1),
+			EXISTS(SELECT 1 FROM slots WHERE id = $2),
+			EXISTS(SELECT 1 FROM usergroups WHERE id = $3);`
 
-	var count int
-	err := s.db.QueryRowContext(ctx, query, userGroupID).Scan(&count)
+	var existence storage.Existence
+	err = s.db.QueryRowContext(ctx, query, bannerID, slotID, userGroupID).
+		Scan(&existence.Banner, &existence.Slot, &existence.UserGroup)
 	if err != nil {
-		return false
+		return storage.Existence{}, err
 	}
 
-	return count > 0
+	return existence, nil
 }
@@ -2,10 +2,13 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
 	st "github.com/cronnoss/banners-rotation/internal/storage"
+	"github.com/jackc/pgx"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 )
 
@@ -117,6 +120,60 @@ func TestClickBanner(t *testing.T) {
 	}
 }
 
+func TestClickImpression(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+	now := time.Now()
+
+	mock.ExpectQuery("UPDATE impressions").
+		WithArgs(2, 3, 4, 7).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "slot_id", "banner_id", "usergroup_id", "created_at"}).
+				AddRow(1, 2, 3, 4, now),
+		)
+	mock.ExpectQuery("UPDATE impressions").
+		WithArgs(2, 3, 4, 7).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT clicked_at IS NOT NULL FROM impressions").
+		WithArgs(2, 3, 4, 7).
+		WillReturnRows(sqlmock.NewRows([]string{"clicked"}).AddRow(true))
+	mock.ExpectQuery("UPDATE impressions").
+		WithArgs(2, 3, 4, 8).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT clicked_at IS NOT NULL FROM impressions").
+		WithArgs(2, 3, 4, 8).
+		WillReturnError(sql.ErrNoRows)
+
+	click, err := storage.ClickImpression(context.Background(), 7, 3, 2, 4)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if click.ID != 1 || click.BannerID != 3 {
+		t.Errorf("unexpected click %+v", click)
+	}
+
+	// The second click of the same impression is rejected.
+	if _, err := storage.ClickImpression(context.Background(), 7, 3, 2, 4); !errors.Is(err, st.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+
+	// An unknown or mismatched impression is not found.
+	if _, err := storage.ClickImpression(context.Background(), 8, 3, 2, 4); !errors.Is(err, st.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
 func TestImpressBanner(t *testing.T) {
 	db, mock, err := sqlmock.Newx()
 	if err != nil {
@@ -126,24 +183,31 @@ func TestImpressBanner(t *testing.T) {
 
 	storage := &Storage{db: db}
 
+	now := time.Now()
 	expectedImpress := &st.Impress{
 		ID:          1,
 		SlotID:      2,
 		BannerID:    3,
 		UserGroupID: 4,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		Status:      st.ImpressStatusViewed,
+		ViewedAt:    &now,
 	}
 
 	mock.ExpectQuery("INSERT INTO impressions").
-		WithArgs(2, 3, 1).
+		WithArgs(2, 3, 1, st.ImpressStatusViewed).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "slot_id", "banner_id", "usergroup_id", "created_at"}).
+			sqlmock.NewRows([]string{
+				"id", "slot_id", "banner_id", "usergroup_id", "created_at", "status", "viewed_at",
+			}).
 				AddRow(
 					expectedImpress.ID,
 					expectedImpress.SlotID,
 					expectedImpress.BannerID,
 					expectedImpress.UserGroupID,
 					expectedImpress.CreatedAt,
+					expectedImpress.Status,
+					expectedImpress.ViewedAt,
 				),
 		)
 
@@ -160,6 +224,12 @@ func TestImpressBanner(t *testing.T) {
 		return
 	}
 
+	if impress.ViewedAt == nil || !impress.ViewedAt.Equal(now) {
+		t.Errorf("unexpected ViewedAt in Impress")
+		return
+	}
+
+	impress.ViewedAt = expectedImpress.ViewedAt
 	if *impress != *expectedImpress {
 		t.Errorf("unexpected values in Impress")
 		return
@@ -187,27 +257,34 @@ func TestPickBanner(t *testing.T) {
 		AddRow(expectedBannerID, 10, 5) // Example values for simulating a banner
 
 	mock.ExpectQuery("SELECT").
-		WithArgs(expectedUserGroupID, expectedSlotID).
+		WithArgs(expectedUserGroupID, expectedSlotID, st.ImpressStatusViewed).
 		WillReturnRows(rows)
 
+	now := time.Now()
 	expectedImpress := &st.Impress{
 		ID:          1,
 		SlotID:      expectedSlotID,
 		BannerID:    expectedBannerID,
 		UserGroupID: expectedUserGroupID,
-		CreatedAt:   time.Now(),
+		CreatedAt:   now,
+		Status:      st.ImpressStatusViewed,
+		ViewedAt:    &now,
 	}
 
 	mock.ExpectQuery("INSERT INTO impressions").
-		WithArgs(expectedSlotID, expectedBannerID, expectedUserGroupID).
+		WithArgs(expectedSlotID, expectedBannerID, expectedUserGroupID, st.ImpressStatusViewed).
 		WillReturnRows(
-			sqlmock.NewRows([]string{"id", "slot_id", "banner_id", "usergroup_id", "created_at"}).
+			sqlmock.NewRows([]string{
+				"id", "slot_id", "banner_id", "usergroup_id", "created_at", "status", "viewed_at",
+			}).
 				AddRow(
 					expectedImpress.ID,
 					expectedImpress.SlotID,
 					expectedImpress.BannerID,
 					expectedImpress.UserGroupID,
 					expectedImpress.CreatedAt,
+					expectedImpress.Status,
+					expectedImpress.ViewedAt,
 				),
 		)
 
@@ -238,3 +315,235 @@ func TestPickBanner(t *testing.T) {
 		t.Errorf("unmet expectations: %s", err)
 	}
 }
+
+func TestPickBannerViewability(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+	storage.SetViewability(true)
+
+	mock.ExpectQuery("SELECT").
+		WithArgs(3, 2, st.ImpressStatusViewed).
+		WillReturnRows(sqlmock.NewRows([]string{"banner_id", "impressions", "clicks"}).AddRow(1, 10, 5))
+
+	mock.ExpectQuery("INSERT INTO impressions").
+		WithArgs(2, 1, 3, st.ImpressStatusServed).
+		WillReturnRows(
+			sqlmock.NewRows([]string{
+				"id", "slot_id", "banner_id", "usergroup_id", "created_at", "status", "viewed_at",
+			}).AddRow(7, 2, 1, 3, time.Now(), st.ImpressStatusServed, nil),
+		)
+
+	impress, _, err := storage.PickBanner(context.Background(), 2, 3)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if impress.Status != st.ImpressStatusServed || impress.ViewedAt != nil {
+		t.Errorf("expected a served impression, got %+v", impress)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+func TestConfirmImpression(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+	now := time.Now()
+
+	mock.ExpectQuery("UPDATE impressions").
+		WithArgs(7, st.ImpressStatusViewed, st.ImpressStatusServed).
+		WillReturnRows(
+			sqlmock.NewRows([]string{
+				"id", "slot_id", "banner_id", "usergroup_id", "created_at", "status", "viewed_at",
+			}).AddRow(7, 2, 1, 3, now, st.ImpressStatusViewed, now),
+		)
+	mock.ExpectQuery("UPDATE impressions").
+		WithArgs(7, st.ImpressStatusViewed, st.ImpressStatusServed).
+		WillReturnError(sql.ErrNoRows)
+
+	impress, err := storage.ConfirmImpression(context.Background(), 7)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if impress.Status != st.ImpressStatusViewed || impress.ViewedAt == nil {
+		t.Errorf("expected a viewed impression, got %+v", impress)
+	}
+
+	// The second confirmation of the same impression is rejected.
+	if _, err := storage.ConfirmImpression(context.Background(), 7); !errors.Is(err, st.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+func TestBannerTargetURL(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+
+	mock.ExpectQuery("SELECT target_url FROM banners").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"target_url"}).AddRow("https://example.com/landing"))
+
+	targetURL, err := storage.BannerTargetURL(context.Background(), 3)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if targetURL != "https://example.com/landing" {
+		t.Errorf("unexpected target URL: %s", targetURL)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAPIKeyRole(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+
+	mock.ExpectQuery("SELECT role FROM api_keys").
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow("admin"))
+
+	role, err := storage.APIKeyRole(context.Background(), "hash")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if role != "admin" {
+		t.Errorf("unexpected role: %s", role)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_keys").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := storage.CountAPIKeys(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 API keys, got %d", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExist(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+
+	mock.ExpectQuery("SELECT").
+		WithArgs(1, 2, 70).
+		WillReturnRows(sqlmock.NewRows([]string{"banner", "slot", "usergroup"}).AddRow(true, true, false))
+
+	existence, err := storage.Exist(context.Background(), 1, 2, 70)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		return
+	}
+
+	if existence != (st.Existence{Banner: true, Slot: true, UserGroup: false}) {
+		t.Errorf("unexpected existence: %+v", existence)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStorageErrors(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		t.Fatalf("failed to create mock: %s", err)
+	}
+	defer db.Close()
+
+	storage := NewStorage(db)
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO rotations").
+		WithArgs(1, 2).
+		WillReturnError(pgx.PgError{Code: uniqueViolation, Detail: "Key (slot_id, banner_id)=(1, 2) already exists."})
+	if err := storage.AddBanner(ctx, 2, 1); !errors.Is(err, st.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO rotations").
+		WithArgs(1, 200).
+		WillReturnError(pgx.PgError{Code: foreignKeyViolation, Detail: "Key (banner_id)=(200) is not present."})
+	if err := storage.AddBanner(ctx, 200, 1); !errors.Is(err, st.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM rotations").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := storage.RemoveBanner(ctx, 2, 1); !errors.Is(err, st.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT").
+		WithArgs(3, 100, st.ImpressStatusViewed).
+		WillReturnRows(sqlmock.NewRows([]string{"banner_id", "impressions", "clicks"}))
+	if _, _, err := storage.PickBanner(ctx, 100, 3); !errors.Is(err, st.ErrNoBanners) {
+		t.Errorf("expected ErrNoBanners, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
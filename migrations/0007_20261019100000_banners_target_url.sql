@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE banners ADD COLUMN IF NOT EXISTS target_url VARCHAR NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE banners DROP COLUMN IF EXISTS target_url;
+-- +goose StatementEnd
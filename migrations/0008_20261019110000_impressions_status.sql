@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Impressions recorded before viewability mode existed are counted as viewed.
+ALTER TABLE impressions ADD COLUMN IF NOT EXISTS status VARCHAR NOT NULL DEFAULT 'viewed';
+ALTER TABLE impressions ADD COLUMN IF NOT EXISTS viewed_at TIMESTAMP NULL;
+CREATE INDEX IF NOT EXISTS impressions_banner_usergroup_status_idx ON impressions (banner_id, usergroup_id, status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS impressions_banner_usergroup_status_idx;
+ALTER TABLE impressions DROP COLUMN IF EXISTS viewed_at;
+ALTER TABLE impressions DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- API keys are stored as the hex SHA-256 of the key, e.g. for a new admin key:
+-- INSERT INTO api_keys (name, role, key_hash, created_at)
+-- VALUES ('ad-ops', 'admin', encode(sha256('<key>'::bytea), 'hex'), NOW());
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id         SERIAL    CONSTRAINT api_keys_pk PRIMARY KEY,
+    name       VARCHAR   NOT NULL,
+    role       VARCHAR   NOT NULL,
+    key_hash   VARCHAR   NOT NULL CONSTRAINT api_keys_key_hash_uq UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A click token is accepted once: the click of an impression is recorded by setting clicked_at.
+ALTER TABLE impressions ADD COLUMN IF NOT EXISTS clicked_at TIMESTAMP NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE impressions DROP COLUMN IF EXISTS clicked_at;
+-- +goose StatementEnd
@@ -10,6 +10,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/cronnoss/banners-rotation/internal/logger"
 	"github.com/cronnoss/banners-rotation/internal/rmq"
 	"github.com/cronnoss/banners-rotation/internal/server/pb"
 	"github.com/cronnoss/banners-rotation/internal/storage"
@@ -18,6 +19,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
@@ -67,6 +69,7 @@ func (s *BannerSuite) SetupSuite() {
 		"1s",
 		2,
 		"15s",
+		logger.New("info", os.Stdout),
 	)
 	s.Require().NoError(err)
 
@@ -150,8 +153,8 @@ func (s *BannerSuite) TestBanner_RemoveBanner() {
 		expectedError bool
 	}{
 		{"An existing banner", 1, 1, false},
-		{"Slot does not exist", 100, 1, false},
-		{"Banner does not exist", 1, 100, false},
+		{"Slot does not exist", 100, 1, true},
+		{"Banner does not exist", 1, 100, true},
 	}
 
 	for _, test := range tests {
@@ -165,6 +168,7 @@ func (s *BannerSuite) TestBanner_RemoveBanner() {
 
 			if test.expectedError {
 				s.Require().Error(err)
+				s.Equal(codes.NotFound, status.Code(err))
 			} else {
 				s.Require().NoError(err)
 				s.NotNil(resp)
@@ -300,7 +304,10 @@ func (s *BannerSuite) getCountRecordInClicksTable(slotID, bannerID, userGroupID
 }
 
 func (s *BannerSuite) getRecordInClicksTable(slotID, bannerID, userGroupID int32) (*storage.Click, error) {
-	query := `SELECT * FROM clicks WHERE slot_id = $1 AND banner_id = $2 AND usergroup_id = $3;`
+	query := `
+	SELECT id, slot_id, banner_id, usergroup_id, created_at FROM clicks
+	WHERE slot_id = $1 AND banner_id = $2 AND usergroup_id = $3;
+	`
 	row := s.db.QueryRow(query, slotID, bannerID, userGroupID)
 
 	click := &storage.Click{}
@@ -314,8 +321,9 @@ func (s *BannerSuite) getRecordInClicksTable(slotID, bannerID, userGroupID int32
 
 func (s *BannerSuite) getRecordInImpressionsTable(slotID, bannerID, userGroupID int32) (*storage.Impress, error) {
 	query := `
-	SELECT * FROM impressions WHERE slot_id = $1 AND banner_id = $2 AND usergroup_id = $3 
-	ORDER BY created_at desc 
+	SELECT id, slot_id, banner_id, usergroup_id, created_at FROM impressions
+	WHERE slot_id = $1 AND banner_id = $2 AND usergroup_id = $3
+	ORDER BY created_at desc
 	LIMIT 1;
 	`
 	row := s.db.QueryRow(query, slotID, bannerID, userGroupID)